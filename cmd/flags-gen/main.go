@@ -1,21 +1,42 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/yuvalwz/flags-gen/pkg/analysis"
+	"github.com/yuvalwz/flags-gen/pkg/flagsgen"
 	"github.com/yuvalwz/flags-gen/pkg/generator"
+	"github.com/yuvalwz/flags-gen/pkg/lint"
 	"github.com/yuvalwz/flags-gen/pkg/parser"
+	"github.com/yuvalwz/flags-gen/pkg/types"
 )
 
 var (
-	inputFile  string
-	outputFile string
-	version    = "dev"
+	inputFile   string
+	outputFile  string
+	initialisms []string
+	naming      string
+	version     = "dev"
+
+	manifestFile    string
+	collisionChecks bool
+	strict          bool
+	typeCheck       bool
+	description     string
+
+	lintFormat string
+
+	inspectOutput string
+
+	configFormat string
+	configStruct string
+	configOutput string
 )
 
 func main() {
@@ -33,6 +54,20 @@ Example:
 
 	rootCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input Go file containing structs with +flags-gen annotations (required)")
 	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file for generated flags code (optional, defaults to <input>_flags.go)")
+	rootCmd.Flags().BoolVar(&strict, "strict", true,
+		"Fail on exported fields with unsupported types or invalid defaults instead of skipping the flag or default")
+	rootCmd.Flags().BoolVar(&typeCheck, "type-check", false,
+		"Type-check default expressions against their field types, loading the input file's package")
+	rootCmd.Flags().StringVar(&description, "description", "first-sentence",
+		"Part of field doc comments used as flag usages in --help (first-sentence, full); a usage tag overrides it")
+	rootCmd.Flags().BoolVar(&collisionChecks, "check-collisions", true,
+		"Fail when the generated structs define the same flag name or shorthand, or one of cobra's built-in flags")
+	rootCmd.Flags().StringVar(&manifestFile, "manifest", "",
+		"JSON manifest declaring which structs each binary registers on one FlagSet, checked for collisions instead of all structs together")
+	rootCmd.PersistentFlags().StringVar(&naming, "naming", "kebab",
+		fmt.Sprintf("Flag naming strategy (%s)", strings.Join(parser.NamingStrategyNames(), ", ")))
+	rootCmd.PersistentFlags().StringSliceVar(&initialisms, "initialisms", nil,
+		"Extra acronyms kept as one word in flag names, added to the built-in list (e.g. PVC,GKE)")
 	if err := rootCmd.MarkFlagRequired("input"); err != nil {
 		fmt.Fprintf(os.Stderr, "Error marking input flag as required: %v\n", err)
 		os.Exit(1)
@@ -46,112 +81,243 @@ Example:
 		},
 	}
 
-	rootCmd.AddCommand(versionCmd)
+	exampleConfigCmd := &cobra.Command{
+		Use:   "example-config",
+		Short: "Generate a commented sample config file for annotated structs",
+		Long: `example-config generates a sample config file for structs marked with +flags-gen.
+Every key is named after the field's json tag and set to its default value, with
+the field's doc comment above it (YAML and TOML only, JSON has no comments).
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+Example:
+  flags-gen example-config -i types.go --format=yaml
+  flags-gen example-config -i types.go --format=toml --struct=OperatorConfig -o config.toml`,
+		RunE: runExampleConfig,
+	}
+
+	exampleConfigCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input Go file containing structs with +flags-gen annotations (required)")
+	exampleConfigCmd.Flags().StringVarP(&configOutput, "output", "o", "", "Output file for the sample config (optional, defaults to stdout)")
+	exampleConfigCmd.Flags().StringVar(&configFormat, "format", generator.FormatYAML,
+		fmt.Sprintf("Config file format (%s)", strings.Join(generator.ConfigFormats, ", ")))
+	exampleConfigCmd.Flags().StringVar(&configStruct, "struct", "", "Only generate a sample for the named struct")
+	if err := exampleConfigCmd.MarkFlagRequired("input"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error marking input flag as required: %v\n", err)
 		os.Exit(1)
 	}
-}
 
-func runFlagsGen(_ *cobra.Command, _ []string) error {
-	if inputFile == "" {
-		return fmt.Errorf("input file is required")
+	lintCmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Report problems in structs with +flags-gen annotations",
+		Long: `lint checks the fields of structs marked with +flags-gen and reports fields
+with unsupported types (which get no flag), missing or badly worded doc comments,
+defaults that do not parse for the field type and inconsistent json/yaml tags.
+It exits with an error when any issue is found.
+
+Example:
+  flags-gen lint -i types.go
+  flags-gen lint -i types.go --format=json`,
+		SilenceUsage: true,
+		RunE:         runLint,
 	}
 
-	// Validate and clean input file path
-	cleanInputFile, err := validateFilePath(inputFile)
-	if err != nil {
-		return fmt.Errorf("invalid input file path: %w", err)
+	lintCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input Go file containing structs with +flags-gen annotations (required)")
+	lintCmd.Flags().StringVar(&lintFormat, "format", "text", "Output format (text, json)")
+	if err := lintCmd.MarkFlagRequired("input"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error marking input flag as required: %v\n", err)
+		os.Exit(1)
 	}
-	inputFile = cleanInputFile
 
-	// Validate input file exists and is accessible
-	fileInfo, err := os.Stat(inputFile)
-	if os.IsNotExist(err) {
-		return fmt.Errorf("input file %s does not exist\n\nTip: Make sure the file path is correct and the file has a .go extension", inputFile)
+	inspectCmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "Print the parsed flag model of annotated structs as JSON",
+		Long: `inspect prints the structs marked with +flags-gen, their fields and flags as
+parsed by flags-gen, with source positions, as a JSON document for other tools.
+The document has a schemaVersion; fields may be added within a version, while
+removing or changing one bumps it. Defaults of sensitive fields are left out.
+
+Example:
+  flags-gen inspect -i types.go
+  flags-gen inspect -i types.go -o flags.json`,
+		RunE: runInspect,
 	}
-	if err != nil {
-		return fmt.Errorf("cannot access input file %s: %w", inputFile, err)
+
+	inspectCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input Go file containing structs with +flags-gen annotations (required)")
+	inspectCmd.Flags().StringVarP(&inspectOutput, "output", "o", "", "Output file for the JSON document (optional, defaults to stdout)")
+	inspectCmd.Flags().StringVar(&description, "description", "first-sentence",
+		"Part of field doc comments used as flag usages (first-sentence, full); a usage tag overrides it")
+	if err := inspectCmd.MarkFlagRequired("input"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error marking input flag as required: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Check file size to prevent DoS
-	const maxFileSize = 10 * 1024 * 1024 // 10MB
-	if fileInfo.Size() > maxFileSize {
-		return fmt.Errorf("input file %s is too large (%d bytes), maximum allowed size is %d bytes", inputFile, fileInfo.Size(), maxFileSize)
+	rootCmd.AddCommand(versionCmd, exampleConfigCmd, lintCmd, inspectCmd)
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
+}
 
-	// Ensure input file has .go extension
-	if !strings.HasSuffix(strings.ToLower(inputFile), ".go") {
-		return fmt.Errorf("input file must be a Go source file (.go extension)")
+func runFlagsGen(_ *cobra.Command, _ []string) error {
+	mode, err := parser.LookupDescriptionMode(description)
+	if err != nil {
+		return err
+	}
+	strategy, err := parser.LookupNamingStrategy(naming)
+	if err != nil {
+		return err
 	}
 
-	// Generate output file name if not provided
-	if outputFile == "" {
-		dir := filepath.Dir(inputFile)
-		base := strings.TrimSuffix(filepath.Base(inputFile), filepath.Ext(inputFile))
-		outputFile = filepath.Join(dir, base+"_flags.go")
+	cfg := flagsgen.Config{
+		Inputs:              []string{inputFile},
+		Output:              outputFile,
+		Naming:              strategy,
+		Initialisms:         initialisms,
+		Lenient:             !strict,
+		TypeCheck:           typeCheck,
+		Description:         mode,
+		SkipCollisionChecks: !collisionChecks,
+	}
+	if collisionChecks && manifestFile != "" {
+		if cfg.Manifest, err = analysis.LoadManifest(manifestFile); err != nil {
+			return err
+		}
 	}
 
-	// Validate output file path
-	cleanOutputFile, err := validateFilePath(outputFile)
+	result, err := flagsgen.Generate(context.Background(), cfg)
 	if err != nil {
-		return fmt.Errorf("invalid output file path: %w", err)
+		return err
 	}
-	outputFile = cleanOutputFile
 
-	// Parse the input file
-	p := parser.New()
-	structs, err := p.ParseFile(inputFile)
+	fmt.Printf("Generated flags code for %d struct(s) in %s\n", len(result.Structs), result.Files[0].Path)
+	return nil
+}
+
+func runExampleConfig(_ *cobra.Command, _ []string) error {
+	structs, err := parseInput()
 	if err != nil {
-		return fmt.Errorf("failed to parse input file: %w", err)
+		return err
 	}
 
-	if len(structs) == 0 {
-		return fmt.Errorf("no structs with +flags-gen annotation found in %s", inputFile)
+	if configStruct != "" {
+		var selected []types.StructInfo
+		for i := range structs {
+			if structs[i].Name == configStruct {
+				selected = append(selected, structs[i])
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("struct %s with +flags-gen annotation not found in %s", configStruct, inputFile)
+		}
+		structs = selected
+	}
+
+	// Only YAML can hold several documents in one file
+	if len(structs) > 1 && configFormat != generator.FormatYAML {
+		return fmt.Errorf("%s declares %d structs, select one with --struct for %s output", inputFile, len(structs), configFormat)
 	}
 
-	// Generate flags code for all structs
 	g := generator.New()
-	var allGenerated []string
+	var documents []string
 
 	for i := range structs {
-		generated, err := g.GenerateFlags(&structs[i])
+		generated, err := g.GenerateExampleConfig(&structs[i], configFormat)
 		if err != nil {
-			return fmt.Errorf("failed to generate flags for struct %s: %w", structs[i].Name, err)
+			return fmt.Errorf("failed to generate example config for struct %s: %w", structs[i].Name, err)
 		}
-		allGenerated = append(allGenerated, generated)
+		documents = append(documents, generated)
+	}
+
+	output := strings.Join(documents, "---\n")
+	if configOutput == "" {
+		fmt.Print(output)
+		return nil
 	}
 
-	// Write output file
-	output := strings.Join(allGenerated, "\n\n")
-	if err := os.WriteFile(outputFile, []byte(output), 0o600); err != nil {
+	cleanOutputFile, err := flagsgen.CleanPath(configOutput)
+	if err != nil {
+		return fmt.Errorf("invalid output file path: %w", err)
+	}
+	if err := os.WriteFile(cleanOutputFile, []byte(output), 0o600); err != nil {
 		return fmt.Errorf("failed to write output file: %w", err)
 	}
 
-	fmt.Printf("Generated flags code for %d struct(s) in %s\n", len(structs), outputFile)
+	fmt.Fprintf(os.Stderr, "Generated example config for %d struct(s) in %s\n", len(structs), cleanOutputFile)
 	return nil
 }
 
-// validateFilePath validates and cleans a file path to prevent path traversal attacks.
-func validateFilePath(path string) (string, error) {
-	if path == "" {
-		return "", fmt.Errorf("file path cannot be empty")
+func runLint(cmd *cobra.Command, _ []string) error {
+	if lintFormat != "text" && lintFormat != "json" {
+		return fmt.Errorf("unsupported lint format %q (supported: text, json)", lintFormat)
 	}
 
-	// Clean the path to resolve any relative components
-	cleanPath := filepath.Clean(path)
+	// Unsupported field types are reported as lint issues rather than failing the parse
+	structs, err := parseInput(parser.WithStrict(false))
+	if err != nil {
+		return err
+	}
 
-	// Check for suspicious patterns that might indicate path traversal
-	if strings.Contains(cleanPath, "..") {
-		return "", fmt.Errorf("path contains directory traversal patterns")
+	issues := lint.Lint(structs)
+	out := cmd.OutOrStdout()
+	if lintFormat == "json" {
+		if issues == nil {
+			issues = []lint.Issue{}
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(issues); err != nil {
+			return fmt.Errorf("failed to encode issues: %w", err)
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Fprintln(out, issue)
+		}
 	}
 
-	// Convert to absolute path if relative
-	absPath, err := filepath.Abs(cleanPath)
+	if len(issues) > 0 {
+		return fmt.Errorf("found %d issue(s)", len(issues))
+	}
+	return nil
+}
+
+func runInspect(cmd *cobra.Command, _ []string) error {
+	mode, err := parser.LookupDescriptionMode(description)
+	if err != nil {
+		return err
+	}
+
+	structs, err := parseInput(parser.WithDescriptionMode(mode))
+	if err != nil {
+		return err
+	}
+
+	output, err := json.MarshalIndent(types.NewModel(structs), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode structs: %w", err)
+	}
+	output = append(output, '\n')
+
+	if inspectOutput == "" {
+		_, err := cmd.OutOrStdout().Write(output)
+		return err
+	}
+
+	cleanOutputFile, err := flagsgen.CleanPath(inspectOutput)
+	if err != nil {
+		return fmt.Errorf("invalid output file path: %w", err)
+	}
+	if err := os.WriteFile(cleanOutputFile, output, 0o600); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// parseInput validates the input file and returns the annotated structs it declares.
+func parseInput(opts ...parser.Option) ([]types.StructInfo, error) {
+	strategy, err := parser.LookupNamingStrategy(naming)
 	if err != nil {
-		return "", fmt.Errorf("failed to resolve absolute path: %w", err)
+		return nil, err
 	}
 
-	return absPath, nil
+	opts = append([]parser.Option{parser.WithInitialisms(initialisms...), parser.WithNamingStrategy(strategy)}, opts...)
+	return flagsgen.ParseInput(inputFile, opts...)
 }
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/yuvalwz/flags-gen/pkg/types"
 )
 
 func TestCLI_Integration(t *testing.T) {
@@ -181,3 +184,336 @@ func TestCLI_MissingInput(t *testing.T) {
 		t.Errorf("Error output should mention required flag: %s", output)
 	}
 }
+
+func TestCLI_ExampleConfig(t *testing.T) {
+	// Build the binary first
+	buildCmd := exec.Command("go", "build", "-o", "flags-gen-test", ".")
+	buildCmd.Dir = "."
+	if err := buildCmd.Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	defer os.Remove("flags-gen-test")
+
+	exampleFile, err := filepath.Abs(filepath.Join("..", "..", "internal", "testdata", "example.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("./flags-gen-test", "example-config", "-i", exampleFile, "--format=yaml")
+	cmd.Dir = "."
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("example-config command failed: %v\nOutput: %s", err, output)
+	}
+
+	expectedElements := []string{
+		"# OperatorConfig defines configuration options for the operator",
+		"# ProbeAddr is the address the probe endpoint binds to.\nprobeAddr: \":8080\"",
+		"controllers:\n  - \"*\"",
+		`requiredCRDsGracePeriod: "30s"`,
+	}
+
+	outputStr := string(output)
+	for _, element := range expectedElements {
+		if !strings.Contains(outputStr, element) {
+			t.Errorf("example-config output missing expected element: %s", element)
+		}
+	}
+}
+
+func TestCLI_Collisions(t *testing.T) {
+	// Build the binary first
+	buildCmd := exec.Command("go", "build", "-o", "flags-gen-test", ".")
+	buildCmd.Dir = "."
+	if err := buildCmd.Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	defer os.Remove("flags-gen-test")
+
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "config.go")
+	testContent := `package config
+
+// +flags-gen
+type ServerConfig struct {
+	// Port is the server port
+	Port int
+}
+
+// +flags-gen
+type MetricsConfig struct {
+	// Port is the metrics port
+	Port int
+}
+`
+	if err := os.WriteFile(testFile, []byte(testContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	outputFile := filepath.Join(tmpDir, "config_flags.go")
+
+	cmd := exec.Command("./flags-gen-test", "-i", testFile, "-o", outputFile)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected colliding flags to fail generation\nOutput: %s", output)
+	}
+	expected := testFile + ":12:2: flag --port of MetricsConfig.Port collides with ServerConfig.Port at " + testFile + ":6:2"
+	if !strings.Contains(string(output), expected) {
+		t.Errorf("Output missing collision %q\nOutput: %s", expected, output)
+	}
+
+	// Structs used by different binaries may share flag names
+	manifestFile := filepath.Join(tmpDir, "manifest.json")
+	manifest := `{"binaries": [{"name": "server", "structs": [{"name": "ServerConfig"}]}, {"name": "metrics", "structs": [{"name": "MetricsConfig"}]}]}`
+	if err := os.WriteFile(manifestFile, []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmd = exec.Command("./flags-gen-test", "-i", testFile, "-o", outputFile, "--manifest", manifestFile)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("Generation with manifest failed: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestCLI_Strict(t *testing.T) {
+	// Build the binary first
+	buildCmd := exec.Command("go", "build", "-o", "flags-gen-test", ".")
+	buildCmd.Dir = "."
+	if err := buildCmd.Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	defer os.Remove("flags-gen-test")
+
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "config.go")
+	testContent := `package config
+
+// +flags-gen
+type Config struct {
+	// Host is the server host
+	Host string
+
+	// Labels are attached to every request
+	Labels map[string]string
+}
+`
+	if err := os.WriteFile(testFile, []byte(testContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	outputFile := filepath.Join(tmpDir, "config_flags.go")
+
+	output, err := exec.Command("./flags-gen-test", "-i", testFile, "-o", outputFile).CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected unsupported field to fail generation\nOutput: %s", output)
+	}
+	expected := testFile + ":9:2: field Labels has unsupported type map[string]string"
+	if !strings.Contains(string(output), expected) {
+		t.Errorf("Output missing error %q\nOutput: %s", expected, output)
+	}
+
+	output, err = exec.Command("./flags-gen-test", "-i", testFile, "-o", outputFile, "--strict=false").CombinedOutput()
+	if err != nil {
+		t.Fatalf("Generation with --strict=false failed: %v\nOutput: %s", err, output)
+	}
+	generated, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(generated), "Labels") {
+		t.Errorf("Expected no flag for Labels\nGenerated: %s", generated)
+	}
+}
+
+func TestCLI_Description(t *testing.T) {
+	// Build the binary first
+	buildCmd := exec.Command("go", "build", "-o", "flags-gen-test", ".")
+	buildCmd.Dir = "."
+	if err := buildCmd.Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	defer os.Remove("flags-gen-test")
+
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "config.go")
+	testContent := `package config
+
+// +flags-gen
+type Config struct {
+	// Host is the server host. It must resolve.
+	//
+	// Use [net.JoinHostPort] for IPv6 addresses.
+	Host string
+}
+`
+	if err := os.WriteFile(testFile, []byte(testContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	outputFile := filepath.Join(tmpDir, "config_flags.go")
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{expected: `"Host is the server host.")`},
+		{args: []string{"--description=full"}, expected: `"Host is the server host. It must resolve.\n\nUse net.JoinHostPort for IPv6 addresses.")`},
+	}
+	for _, tt := range tests {
+		args := append([]string{"-i", testFile, "-o", outputFile}, tt.args...)
+		if output, err := exec.Command("./flags-gen-test", args...).CombinedOutput(); err != nil {
+			t.Fatalf("Generation with %v failed: %v\nOutput: %s", tt.args, err, output)
+		}
+		generated, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(generated), tt.expected) {
+			t.Errorf("Generated code with %v missing %s\nGenerated: %s", tt.args, tt.expected, generated)
+		}
+	}
+
+	output, err := exec.Command("./flags-gen-test", "-i", testFile, "-o", outputFile, "--description=summary").CombinedOutput()
+	if err == nil || !strings.Contains(string(output), `unknown description mode "summary"`) {
+		t.Errorf("Expected unknown description mode error, got %v\nOutput: %s", err, output)
+	}
+}
+
+func TestCLI_Inspect(t *testing.T) {
+	// Build the binary first
+	buildCmd := exec.Command("go", "build", "-o", "flags-gen-test", ".")
+	buildCmd.Dir = "."
+	if err := buildCmd.Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	defer os.Remove("flags-gen-test")
+
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "config.go")
+	testContent := `package config
+
+import "time"
+
+// +flags-gen
+type Config struct {
+	// Timeout is the request timeout.
+	Timeout time.Duration ` + "`json:\"timeout\" default:\"90s\"`" + `
+
+	// Token authenticates requests.
+	Token string ` + "`default:\"s3cret\" sensitive:\"true\"`" + `
+
+	// MaxRetries is the number of retries.
+	MaxRetries int
+}
+`
+	if err := os.WriteFile(testFile, []byte(testContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := exec.Command("./flags-gen-test", "inspect", "-i", testFile).Output()
+	if err != nil {
+		t.Fatalf("inspect failed: %v\nOutput: %s", err, output)
+	}
+	if strings.Contains(string(output), "s3cret") {
+		t.Errorf("inspect printed a sensitive default:\n%s", output)
+	}
+
+	var model types.Model
+	if err := json.Unmarshal(output, &model); err != nil {
+		t.Fatalf("inspect output is not a model: %v\nOutput: %s", err, output)
+	}
+	if model.SchemaVersion != types.SchemaVersion || len(model.Structs) != 1 || len(model.Structs[0].Fields) != 3 {
+		t.Fatalf("Unexpected model:\n%s", output)
+	}
+	timeout := model.Structs[0].Fields[0]
+	if timeout.FlagName != "timeout" || timeout.DefaultValue != "1m30s" || timeout.Description != "Timeout is the request timeout." {
+		t.Errorf("Unexpected Timeout field: %+v", timeout)
+	}
+	if expected := (types.Position{Filename: testFile, Line: 8, Column: 2}); timeout.Pos != expected {
+		t.Errorf("Timeout position = %v, expected %v", timeout.Pos, expected)
+	}
+
+	if retries := model.Structs[0].Fields[2]; retries.FlagName != "max-retries" {
+		t.Errorf("MaxRetries flag name = %s, expected max-retries", retries.FlagName)
+	}
+
+	// --naming applies to the subcommands too
+	output, err = exec.Command("./flags-gen-test", "inspect", "--naming=snake", "-i", testFile).Output()
+	if err != nil {
+		t.Fatalf("inspect --naming=snake failed: %v\nOutput: %s", err, output)
+	}
+	if err := json.Unmarshal(output, &model); err != nil {
+		t.Fatalf("inspect output is not a model: %v\nOutput: %s", err, output)
+	}
+	if retries := model.Structs[0].Fields[2]; retries.FlagName != "max_retries" {
+		t.Errorf("MaxRetries flag name = %s, expected max_retries", retries.FlagName)
+	}
+
+	outputFile := filepath.Join(tmpDir, "flags.json")
+	if output, err := exec.Command("./flags-gen-test", "inspect", "-i", testFile, "-o", outputFile).CombinedOutput(); err != nil {
+		t.Fatalf("inspect -o failed: %v\nOutput: %s", err, output)
+	}
+	written, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(written), "{\n  \"schemaVersion\": \"v1\",") {
+		t.Errorf("Unexpected JSON document:\n%s", written)
+	}
+}
+
+func TestCLI_Lint(t *testing.T) {
+	// Build the binary first
+	buildCmd := exec.Command("go", "build", "-o", "flags-gen-test", ".")
+	buildCmd.Dir = "."
+	if err := buildCmd.Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	defer os.Remove("flags-gen-test")
+
+	testFile := filepath.Join(t.TempDir(), "config.go")
+	testContent := `package config
+
+// +flags-gen
+type Config struct {
+	// Port is the server port
+	Port int ` + "`json:\"port\" default:\"eighty\"`" + `
+
+	Labels map[string]string
+}
+`
+	if err := os.WriteFile(testFile, []byte(testContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := exec.Command("./flags-gen-test", "lint", "-i", testFile).CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected lint to fail\nOutput: %s", output)
+	}
+	expectedElements := []string{
+		testFile + `:6:2: invalid default for field Port: "eighty" is not a valid int (invalid-default)`,
+		testFile + ":8:2: field Labels has unsupported type map[string]string and gets no flag",
+		"found 2 issue(s)",
+	}
+	for _, element := range expectedElements {
+		if !strings.Contains(string(output), element) {
+			t.Errorf("lint output missing expected element: %s\nOutput: %s", element, output)
+		}
+	}
+
+	output, err = exec.Command("./flags-gen-test", "lint", "-i", testFile, "--format=json").Output()
+	if err == nil {
+		t.Fatal("Expected lint to fail")
+	}
+	var issues []map[string]interface{}
+	if err := json.Unmarshal(output, &issues); err != nil {
+		t.Fatalf("lint --format=json output is not JSON: %v\nOutput: %s", err, output)
+	}
+	if len(issues) != 2 || issues[1]["rule"] != "unsupported-type" {
+		t.Errorf("Unexpected JSON issues: %s", output)
+	}
+
+	exampleFile, err := filepath.Abs(filepath.Join("..", "..", "internal", "testdata", "example.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command("./flags-gen-test", "lint", "-i", exampleFile).CombinedOutput(); err != nil {
+		t.Errorf("Expected example.go to pass lint: %v\nOutput: %s", err, output)
+	}
+}
@@ -0,0 +1,119 @@
+// Package analysis checks the flags of several annotated structs against each
+// other, such as for name collisions when they are registered on one FlagSet.
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+// builtinFlags lists the flags cobra adds to every command. A struct defining
+// one of them silently replaces cobra's own flag.
+var builtinFlags = map[string]bool{
+	"--help":    true,
+	"-h":        true,
+	"--version": true,
+}
+
+// Usage is a struct registered on a FlagSet. Prefix is the runtime prefix passed
+// to its WithPrefix methods, empty when the plain methods are used.
+type Usage struct {
+	Struct *types.StructInfo
+	Prefix string
+}
+
+// Registration is a field registering a flag.
+type Registration struct {
+	Struct string
+	Field  string
+	Prefix string
+	Pos    types.Position
+}
+
+// String returns the field as "Struct.Field", with its runtime prefix if any.
+func (r Registration) String() string {
+	if r.Prefix != "" {
+		return fmt.Sprintf("%s.%s (prefix %q)", r.Struct, r.Field, r.Prefix)
+	}
+	return r.Struct + "." + r.Field
+}
+
+// Collision is a flag name or shorthand registered twice on one FlagSet, which
+// makes pflag panic at runtime, or a flag clashing with one of cobra's built-in flags.
+type Collision struct {
+	// Binary is the manifest binary owning the FlagSet, empty without a manifest.
+	Binary string
+	// Flag is the colliding flag as written on the command line, e.g. "--port" or "-p".
+	Flag string
+	// Field is the field registering Flag last.
+	Field Registration
+	// Other is the field that registered Flag first. It is empty for built-in flags.
+	Other Registration
+	// Builtin is set when Flag is one of cobra's built-in flags.
+	Builtin bool
+}
+
+// Error returns the collision as a "file:line:col: message" error string.
+func (c Collision) Error() string {
+	var in string
+	if c.Binary != "" {
+		in = " in binary " + c.Binary
+	}
+	if c.Builtin {
+		return fmt.Sprintf("%s: flag %s of %s clashes with cobra's built-in %s flag%s", c.Field.Pos, c.Flag, c.Field, c.Flag, in)
+	}
+	return fmt.Sprintf("%s: flag %s of %s collides with %s at %s%s", c.Field.Pos, c.Flag, c.Field, c.Other, c.Other.Pos, in)
+}
+
+// FindCollisions returns the collisions between the flags of structs registered
+// on one FlagSet in the given order: long names (including aliases and -file
+// flags), shorthands, and cobra's built-in --help, -h and --version flags.
+func FindCollisions(usages []Usage) []Collision {
+	var collisions []Collision
+	registered := make(map[string]Registration)
+
+	for _, usage := range usages {
+		for i := range usage.Struct.Fields {
+			field := &usage.Struct.Fields[i]
+			if field.FlagMethod == "" {
+				continue
+			}
+
+			registration := Registration{
+				Struct: usage.Struct.Name,
+				Field:  field.Name,
+				Prefix: usage.Prefix,
+				Pos:    field.Pos,
+			}
+			for _, flag := range fieldFlags(field, usage.Prefix) {
+				if builtinFlags[flag] {
+					collisions = append(collisions, Collision{Flag: flag, Field: registration, Builtin: true})
+				}
+				if other, ok := registered[flag]; ok {
+					collisions = append(collisions, Collision{Flag: flag, Field: registration, Other: other})
+					continue
+				}
+				registered[flag] = registration
+			}
+		}
+	}
+
+	return collisions
+}
+
+// fieldFlags returns the flags a field registers as written on the command line.
+// Shorthands cannot be prefixed, so they are only registered without a runtime prefix.
+func fieldFlags(field *types.FieldInfo, prefix string) []string {
+	flags := []string{"--" + prefix + field.FlagName}
+	for _, alias := range field.Aliases {
+		flags = append(flags, "--"+prefix+alias)
+	}
+	if field.FromFile {
+		flags = append(flags, "--"+prefix+field.FileFlagName())
+	}
+	if field.ShortFlag != "" && prefix == "" {
+		flags = append(flags, "-"+field.ShortFlag)
+	}
+	return flags
+}
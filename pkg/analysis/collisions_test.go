@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+func testStruct(name string, fields ...types.FieldInfo) *types.StructInfo {
+	for i := range fields {
+		fields[i].FlagMethod = "StringVar"
+		fields[i].Pos = types.Position{Filename: "config.go", Line: i + 1, Column: 2}
+	}
+	return &types.StructInfo{Name: name, Fields: fields, WithPrefix: true}
+}
+
+func TestFindCollisions(t *testing.T) {
+	server := testStruct("ServerConfig",
+		types.FieldInfo{Name: "Port", FlagName: "port", ShortFlag: "p"},
+		types.FieldInfo{Name: "Token", FlagName: "token", FromFile: true},
+	)
+	client := testStruct("ClientConfig",
+		types.FieldInfo{Name: "Addr", FlagName: "addr", Aliases: []string{"port"}},
+		types.FieldInfo{Name: "TokenFile", FlagName: "token-file"},
+		types.FieldInfo{Name: "Peer", FlagName: "peer", ShortFlag: "p"},
+		types.FieldInfo{Name: "ShowHelp", FlagName: "help", ShortFlag: "h"},
+	)
+
+	collisions := FindCollisions([]Usage{{Struct: server}, {Struct: client}})
+
+	expected := []string{
+		"config.go:1:2: flag --port of ClientConfig.Addr collides with ServerConfig.Port at config.go:1:2",
+		"config.go:2:2: flag --token-file of ClientConfig.TokenFile collides with ServerConfig.Token at config.go:2:2",
+		"config.go:3:2: flag -p of ClientConfig.Peer collides with ServerConfig.Port at config.go:1:2",
+		"config.go:4:2: flag --help of ClientConfig.ShowHelp clashes with cobra's built-in --help flag",
+		"config.go:4:2: flag -h of ClientConfig.ShowHelp clashes with cobra's built-in -h flag",
+	}
+	if len(collisions) != len(expected) {
+		t.Fatalf("Expected %d collisions, got %d: %v", len(expected), len(collisions), collisions)
+	}
+	for i, collision := range collisions {
+		if collision.Error() != expected[i] {
+			t.Errorf("Collision %d:\n got %s\nwant %s", i, collision.Error(), expected[i])
+		}
+	}
+}
+
+func TestFindCollisions_Prefix(t *testing.T) {
+	client := testStruct("ClientConfig",
+		types.FieldInfo{Name: "Server", FlagName: "server"},
+		types.FieldInfo{Name: "Insecure", FlagName: "insecure", ShortFlag: "k"},
+	)
+
+	// Long names are prefixed, shorthands are only registered without a prefix
+	collisions := FindCollisions([]Usage{{Struct: client}, {Struct: client, Prefix: "source-"}, {Struct: client, Prefix: "target-"}})
+	if len(collisions) != 0 {
+		t.Fatalf("Expected no collisions, got %v", collisions)
+	}
+
+	kube := testStruct("KubeConfig", types.FieldInfo{Name: "SkipVerify", FlagName: "skip-verify", ShortFlag: "k"})
+	collisions = FindCollisions([]Usage{{Struct: client}, {Struct: client, Prefix: "target-"}, {Struct: kube}})
+	if len(collisions) != 1 {
+		t.Fatalf("Expected 1 collision, got %d: %v", len(collisions), collisions)
+	}
+	expected := "flag -k of KubeConfig.SkipVerify collides with ClientConfig.Insecure"
+	if !strings.Contains(collisions[0].Error(), expected) {
+		t.Errorf("Collision %q does not contain %q", collisions[0].Error(), expected)
+	}
+}
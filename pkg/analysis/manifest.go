@@ -0,0 +1,89 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+// Manifest declares how structs are composed into binaries: each binary
+// registers its structs on one FlagSet, so their flags must not collide.
+//
+//	{
+//	  "binaries": [
+//	    {
+//	      "name": "operator",
+//	      "structs": [
+//	        {"name": "OperatorConfig"},
+//	        {"name": "ClientConfig", "prefix": "source-"},
+//	        {"name": "ClientConfig", "prefix": "target-"}
+//	      ]
+//	    }
+//	  ]
+//	}
+type Manifest struct {
+	Binaries []Binary `json:"binaries"`
+}
+
+// Binary is a program registering the flags of several structs on one FlagSet.
+type Binary struct {
+	Name    string        `json:"name"`
+	Structs []StructUsage `json:"structs"`
+}
+
+// StructUsage names a struct registered by a binary. Prefix is the runtime prefix
+// passed to its WithPrefix methods.
+type StructUsage struct {
+	Name   string `json:"name"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// LoadManifest reads a JSON manifest from path.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var manifest Manifest
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest %s: %w", path, err)
+	}
+	return &manifest, nil
+}
+
+// FindCollisions returns the flag collisions within each binary of the manifest.
+// It fails when a binary uses a struct that is not in structs, or passes a prefix
+// to a struct without +flags-gen:with-prefix.
+func (m *Manifest) FindCollisions(structs []types.StructInfo) ([]Collision, error) {
+	byName := make(map[string]*types.StructInfo, len(structs))
+	for i := range structs {
+		byName[structs[i].Name] = &structs[i]
+	}
+
+	var collisions []Collision
+	for _, binary := range m.Binaries {
+		usages := make([]Usage, 0, len(binary.Structs))
+		for _, used := range binary.Structs {
+			structInfo, ok := byName[used.Name]
+			if !ok {
+				return nil, fmt.Errorf("binary %s uses struct %s, which has no +flags-gen annotation in the input", binary.Name, used.Name)
+			}
+			if used.Prefix != "" && !structInfo.WithPrefix {
+				return nil, fmt.Errorf("binary %s uses struct %s with prefix %q, but it has no +flags-gen:with-prefix marker", binary.Name, used.Name, used.Prefix)
+			}
+			usages = append(usages, Usage{Struct: structInfo, Prefix: used.Prefix})
+		}
+
+		for _, collision := range FindCollisions(usages) {
+			collision.Binary = binary.Name
+			collisions = append(collisions, collision)
+		}
+	}
+	return collisions, nil
+}
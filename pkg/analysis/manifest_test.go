@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+func writeManifest(t *testing.T, content string) *Manifest {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	manifest, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	return manifest
+}
+
+func TestManifest_FindCollisions(t *testing.T) {
+	structs := []types.StructInfo{
+		*testStruct("OperatorConfig", types.FieldInfo{Name: "Server", FlagName: "server"}),
+		*testStruct("ClientConfig", types.FieldInfo{Name: "Server", FlagName: "server"}),
+	}
+
+	// Each binary is checked on its own
+	manifest := writeManifest(t, `{"binaries": [
+		{"name": "operator", "structs": [{"name": "OperatorConfig"}, {"name": "ClientConfig", "prefix": "source-"}]},
+		{"name": "cli", "structs": [{"name": "ClientConfig"}]}
+	]}`)
+	collisions, err := manifest.FindCollisions(structs)
+	if err != nil {
+		t.Fatalf("FindCollisions failed: %v", err)
+	}
+	if len(collisions) != 0 {
+		t.Errorf("Expected no collisions, got %v", collisions)
+	}
+
+	manifest = writeManifest(t, `{"binaries": [
+		{"name": "operator", "structs": [{"name": "OperatorConfig"}, {"name": "ClientConfig"}]}
+	]}`)
+	collisions, err = manifest.FindCollisions(structs)
+	if err != nil {
+		t.Fatalf("FindCollisions failed: %v", err)
+	}
+	if len(collisions) != 1 || collisions[0].Binary != "operator" || !strings.HasSuffix(collisions[0].Error(), " in binary operator") {
+		t.Errorf("Expected one collision in binary operator, got %v", collisions)
+	}
+}
+
+func TestManifest_Errors(t *testing.T) {
+	structs := []types.StructInfo{{Name: "Config"}}
+
+	tests := []struct {
+		name     string
+		manifest string
+		expected string
+	}{
+		{
+			name:     "unknown struct",
+			manifest: `{"binaries": [{"name": "app", "structs": [{"name": "Missing"}]}]}`,
+			expected: "binary app uses struct Missing, which has no +flags-gen annotation in the input",
+		},
+		{
+			name:     "prefix without with-prefix",
+			manifest: `{"binaries": [{"name": "app", "structs": [{"name": "Config", "prefix": "x-"}]}]}`,
+			expected: `binary app uses struct Config with prefix "x-", but it has no +flags-gen:with-prefix marker`,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := writeManifest(t, test.manifest).FindCollisions(structs)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: error %v does not contain %q", test.name, err, test.expected)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(path, []byte(`{"binary": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadManifest(path); err == nil {
+		t.Error("Expected LoadManifest to reject unknown keys")
+	}
+}
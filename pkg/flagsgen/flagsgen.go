@@ -0,0 +1,258 @@
+// Package flagsgen runs flags-gen as a library: it parses Go files for structs
+// with +flags-gen annotations, checks their flags for collisions and writes
+// the generated flags code, as the flags-gen command does.
+package flagsgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuvalwz/flags-gen/pkg/analysis"
+	"github.com/yuvalwz/flags-gen/pkg/generator"
+	"github.com/yuvalwz/flags-gen/pkg/parser"
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+// BackendPFlag is the backend generating code for github.com/spf13/pflag.
+const BackendPFlag = "pflag"
+
+// maxInputSize is the size limit of input files.
+const maxInputSize = 10 * 1024 * 1024 // 10MB
+
+// Config configures Generate. The zero value of every field but Inputs
+// selects the default of the flags-gen command.
+type Config struct {
+	// Inputs are the Go files declaring structs with +flags-gen annotations.
+	Inputs []string
+	// Output is the file the code for a single input is written to. By
+	// default the code for each input <name>.go goes to <name>_flags.go next to it.
+	Output string
+	// Backend is the flag package the generated code registers flags with.
+	// Only BackendPFlag, the default, is supported.
+	Backend string
+	// Naming derives flag names from field names, parser.KebabCase by default.
+	Naming parser.NamingStrategy
+	// Initialisms are acronyms kept as one word in flag names, in addition
+	// to parser.DefaultInitialisms.
+	Initialisms []string
+	// Lenient leaves exported fields with unsupported types without a flag and
+	// invalid defaults unset instead of failing, like --strict=false.
+	Lenient bool
+	// TypeCheck type-checks default expressions against their field types.
+	TypeCheck bool
+	// Description selects the part of doc comments used as flag usages.
+	Description parser.DescriptionMode
+	// SkipCollisionChecks generates code even when flags of the structs collide.
+	SkipCollisionChecks bool
+	// Manifest declares which structs each binary registers on one FlagSet.
+	// Collisions are then checked per binary rather than across all structs.
+	Manifest *analysis.Manifest
+	// DryRun generates the files without writing them.
+	DryRun bool
+}
+
+// File is a generated Go file.
+type File struct {
+	// Path is the absolute path of the file.
+	Path string
+	// Input is the absolute path of the input file it was generated from.
+	Input string
+	// Structs are the names of the structs the file holds flags code for.
+	Structs []string
+	// Content is the generated Go source.
+	Content []byte
+}
+
+// Result lists what Generate produced.
+type Result struct {
+	// Files are the generated files, one per input.
+	Files []File
+	// Structs are the annotated structs of all inputs, in input order.
+	Structs []types.StructInfo
+}
+
+// Generate parses the inputs of cfg and writes the flags code for their
+// annotated structs. Nothing is written when parsing, the collision checks or
+// generation fail for any input.
+func Generate(ctx context.Context, cfg Config) (Result, error) {
+	if len(cfg.Inputs) == 0 {
+		return Result{}, fmt.Errorf("input file is required")
+	}
+	if cfg.Output != "" && len(cfg.Inputs) > 1 {
+		return Result{}, fmt.Errorf("an output file can only be given for a single input, got %d inputs", len(cfg.Inputs))
+	}
+	if cfg.Backend != "" && cfg.Backend != BackendPFlag {
+		return Result{}, fmt.Errorf("unsupported backend %q (supported: %s)", cfg.Backend, BackendPFlag)
+	}
+
+	opts := []parser.Option{
+		parser.WithInitialisms(cfg.Initialisms...),
+		parser.WithStrict(!cfg.Lenient),
+		parser.WithTypeCheck(cfg.TypeCheck),
+		parser.WithDescriptionMode(cfg.Description),
+	}
+	if cfg.Naming != nil {
+		opts = append(opts, parser.WithNamingStrategy(cfg.Naming))
+	}
+
+	var result Result
+	for _, input := range cfg.Inputs {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		cleanInput, err := CleanPath(input)
+		if err != nil {
+			return Result{}, fmt.Errorf("invalid input file path: %w", err)
+		}
+		structs, err := ParseInput(cleanInput, opts...)
+		if err != nil {
+			return Result{}, err
+		}
+
+		output := cfg.Output
+		if output == "" {
+			base := strings.TrimSuffix(filepath.Base(cleanInput), filepath.Ext(cleanInput))
+			output = filepath.Join(filepath.Dir(cleanInput), base+"_flags.go")
+		}
+		cleanOutput, err := CleanPath(output)
+		if err != nil {
+			return Result{}, fmt.Errorf("invalid output file path: %w", err)
+		}
+
+		file := File{Path: cleanOutput, Input: cleanInput}
+		for i := range structs {
+			file.Structs = append(file.Structs, structs[i].Name)
+		}
+		result.Files = append(result.Files, file)
+		result.Structs = append(result.Structs, structs...)
+	}
+
+	if !cfg.SkipCollisionChecks {
+		if err := checkCollisions(result.Structs, cfg.Manifest); err != nil {
+			return Result{}, err
+		}
+	}
+
+	g := generator.New()
+	next := 0
+	for i := range result.Files {
+		structs := result.Structs[next : next+len(result.Files[i].Structs)]
+		next += len(structs)
+		generated, err := g.GenerateFile(structs)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to generate flags for %s: %w", result.Files[i].Input, err)
+		}
+		result.Files[i].Content = []byte(generated)
+	}
+
+	if cfg.DryRun {
+		return result, nil
+	}
+	for _, file := range result.Files {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		if err := os.WriteFile(file.Path, file.Content, 0o600); err != nil {
+			return Result{}, fmt.Errorf("failed to write output file: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// ParseInput validates the Go file at path and returns the annotated structs
+// it declares. It fails when the file declares none.
+func ParseInput(path string, opts ...parser.Option) ([]types.StructInfo, error) {
+	if path == "" {
+		return nil, fmt.Errorf("input file is required")
+	}
+
+	cleanPath, err := CleanPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input file path: %w", err)
+	}
+
+	// Validate input file exists and is accessible
+	fileInfo, err := os.Stat(cleanPath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("input file %s does not exist\n\nTip: Make sure the file path is correct and the file has a .go extension", cleanPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot access input file %s: %w", cleanPath, err)
+	}
+
+	// Check file size to prevent DoS
+	if fileInfo.Size() > maxInputSize {
+		return nil, fmt.Errorf("input file %s is too large (%d bytes), maximum allowed size is %d bytes", cleanPath, fileInfo.Size(), maxInputSize)
+	}
+
+	// Ensure input file has .go extension
+	if !strings.HasSuffix(strings.ToLower(cleanPath), ".go") {
+		return nil, fmt.Errorf("input file must be a Go source file (.go extension)")
+	}
+
+	structs, err := parser.New(opts...).ParseFile(cleanPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse input file: %w", err)
+	}
+
+	if len(structs) == 0 {
+		return nil, fmt.Errorf("no structs with +flags-gen annotation found in %s", cleanPath)
+	}
+
+	return structs, nil
+}
+
+// CleanPath validates and cleans a file path to prevent path traversal
+// attacks, returning it as an absolute path.
+func CleanPath(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("file path cannot be empty")
+	}
+
+	// Clean the path to resolve any relative components
+	cleanPath := filepath.Clean(path)
+
+	// Check for suspicious patterns that might indicate path traversal
+	if strings.Contains(cleanPath, "..") {
+		return "", fmt.Errorf("path contains directory traversal patterns")
+	}
+
+	// Convert to absolute path if relative
+	absPath, err := filepath.Abs(cleanPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+
+	return absPath, nil
+}
+
+// checkCollisions fails when flags of the structs collide: within each binary of
+// the manifest if one is given, otherwise across all structs registered together.
+func checkCollisions(structs []types.StructInfo, manifest *analysis.Manifest) error {
+	var collisions []analysis.Collision
+	if manifest != nil {
+		var err error
+		if collisions, err = manifest.FindCollisions(structs); err != nil {
+			return err
+		}
+	} else {
+		usages := make([]analysis.Usage, len(structs))
+		for i := range structs {
+			usages[i] = analysis.Usage{Struct: &structs[i]}
+		}
+		collisions = analysis.FindCollisions(usages)
+	}
+
+	if len(collisions) == 0 {
+		return nil
+	}
+	errs := make([]error, len(collisions))
+	for i := range collisions {
+		errs[i] = collisions[i]
+	}
+	return fmt.Errorf("found %d flag collision(s):\n%w", len(collisions), errors.Join(errs...))
+}
@@ -0,0 +1,201 @@
+package flagsgen
+
+import (
+	"context"
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuvalwz/flags-gen/pkg/parser"
+)
+
+const serverSource = `package config
+
+import "time"
+
+// ServerConfig configures the server.
+// +flags-gen
+type ServerConfig struct {
+	// Addr is the listen address.
+	Addr string ` + "`default:\":8080\"`" + `
+
+	// Timeout is the request timeout.
+	Timeout time.Duration ` + "`default:\"30s\"`" + `
+}
+
+// ClientConfig configures the client.
+// +flags-gen
+// +flags-gen:prefix=client
+type ClientConfig struct {
+	// Retries is the number of retries.
+	Retries int ` + "`default:\"3\"`" + `
+}
+`
+
+const metricsSource = `package config
+
+// MetricsConfig configures metrics.
+// +flags-gen
+type MetricsConfig struct {
+	// MetricsAddr is the metrics address.
+	MetricsAddr string
+}
+`
+
+// writeInputs writes the named sources to a temporary directory and returns their paths.
+func writeInputs(t *testing.T, sources map[string]string) map[string]string {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := make(map[string]string, len(sources))
+	for name, source := range sources {
+		paths[name] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[name], []byte(source), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+func TestGenerate(t *testing.T) {
+	paths := writeInputs(t, map[string]string{"server.go": serverSource, "metrics.go": metricsSource})
+
+	result, err := Generate(context.Background(), Config{Inputs: []string{paths["server.go"], paths["metrics.go"]}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if len(result.Files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(result.Files))
+	}
+	expected := []File{
+		{Path: strings.TrimSuffix(paths["server.go"], ".go") + "_flags.go", Input: paths["server.go"], Structs: []string{"ServerConfig", "ClientConfig"}},
+		{Path: strings.TrimSuffix(paths["metrics.go"], ".go") + "_flags.go", Input: paths["metrics.go"], Structs: []string{"MetricsConfig"}},
+	}
+	for i, file := range result.Files {
+		if file.Path != expected[i].Path || file.Input != expected[i].Input || !reflect.DeepEqual(file.Structs, expected[i].Structs) {
+			t.Errorf("File %d = %s from %s with %v, expected %s from %s with %v",
+				i, file.Path, file.Input, file.Structs, expected[i].Path, expected[i].Input, expected[i].Structs)
+		}
+
+		written, err := os.ReadFile(file.Path)
+		if err != nil {
+			t.Fatalf("Reading %s failed: %v", file.Path, err)
+		}
+		if string(written) != string(file.Content) {
+			t.Errorf("Written %s differs from Result content", file.Path)
+		}
+	}
+	if len(result.Structs) != 3 {
+		t.Errorf("Expected 3 structs, got %d", len(result.Structs))
+	}
+
+	// Both structs of server.go share one package clause and import block
+	content := string(result.Files[0].Content)
+	if _, err := goparser.ParseFile(token.NewFileSet(), "server_flags.go", content, 0); err != nil {
+		t.Fatalf("Generated file does not parse: %v\n%s", err, content)
+	}
+	for _, element := range []string{"package config", `"time"`, "func (o *ServerConfig) AddFlags(", "func (o *ClientConfig) AddFlags("} {
+		if strings.Count(content, element) != 1 {
+			t.Errorf("Expected %s once in the generated file, got %d\n%s", element, strings.Count(content, element), content)
+		}
+	}
+}
+
+func TestGenerate_Options(t *testing.T) {
+	paths := writeInputs(t, map[string]string{"server.go": serverSource})
+	output := filepath.Join(filepath.Dir(paths["server.go"]), "flags.go")
+
+	result, err := Generate(context.Background(), Config{
+		Inputs: []string{paths["server.go"]},
+		Output: output,
+		Naming: parser.SnakeCase,
+		DryRun: true,
+	})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if result.Files[0].Path != output {
+		t.Errorf("Path = %s, expected %s", result.Files[0].Path, output)
+	}
+	if !strings.Contains(string(result.Files[0].Content), `"client_retries"`) {
+		t.Errorf("Expected snake_case flag names:\n%s", result.Files[0].Content)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("Dry run wrote %s", output)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	paths := writeInputs(t, map[string]string{
+		"server.go":  serverSource,
+		"metrics.go": metricsSource,
+		"clash.go":   "package config\n\n// +flags-gen\ntype Clash struct {\n\tAddr string\n}\n",
+		"labels.go":  "package config\n\n// +flags-gen\ntype Labels struct {\n\tLabels map[string]string\n}\n",
+	})
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		cfg      Config
+		expected string
+	}{
+		{name: "no inputs", cfg: Config{}, expected: "input file is required"},
+		{
+			name:     "output for several inputs",
+			cfg:      Config{Inputs: []string{paths["server.go"], paths["metrics.go"]}, Output: "flags.go"},
+			expected: "an output file can only be given for a single input, got 2 inputs",
+		},
+		{
+			name:     "unsupported backend",
+			cfg:      Config{Inputs: []string{paths["server.go"]}, Backend: "flag"},
+			expected: `unsupported backend "flag" (supported: pflag)`,
+		},
+		{
+			name:     "collision across inputs",
+			cfg:      Config{Inputs: []string{paths["server.go"], paths["clash.go"]}},
+			expected: "found 1 flag collision(s)",
+		},
+		{
+			name:     "strict",
+			cfg:      Config{Inputs: []string{paths["labels.go"]}},
+			expected: "field Labels has unsupported type map[string]string",
+		},
+		{
+			name:     "canceled",
+			ctx:      canceled,
+			cfg:      Config{Inputs: []string{paths["server.go"]}},
+			expected: context.Canceled.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			tt.cfg.DryRun = true
+			_, err := Generate(ctx, tt.cfg)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+
+	// Lenient generation leaves the unsupported field without a flag
+	if _, err := Generate(context.Background(), Config{Inputs: []string{paths["labels.go"]}, Lenient: true, DryRun: true}); err != nil {
+		t.Errorf("Lenient Generate failed: %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+// Package flagsrt is the runtime support library for code generated by flags-gen.
+// It records where each flag value came from (default, config file, environment
+// or command line) and overlays config files onto a parsed FlagSet.
+package flagsrt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Source describes where the value of a flag came from.
+type Source int
+
+// Flag value sources, from lowest to highest precedence.
+const (
+	SourceDefault Source = iota
+	SourceConfigFile
+	SourceEnv
+	SourceFlag
+)
+
+var sourceNames = [...]string{
+	SourceDefault:    "default",
+	SourceConfigFile: "config-file",
+	SourceEnv:        "env",
+	SourceFlag:       "flag",
+}
+
+// String returns the name of the source, e.g. "config-file".
+func (s Source) String() string {
+	if s < 0 || int(s) >= len(sourceNames) {
+		return fmt.Sprintf("Source(%d)", int(s))
+	}
+	return sourceNames[s]
+}
+
+// MarshalText implements encoding.TextMarshaler so sources encode by name.
+func (s Source) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// SourceAnnotation is the pflag annotation key under which Set records the
+// source of a value that was not given on the command line.
+const SourceAnnotation = "flags-gen/source"
+
+// Set sets the named flag to value and records source as its origin.
+func Set(flags *pflag.FlagSet, name, value string, source Source) error {
+	if err := flags.Set(name, value); err != nil {
+		return err
+	}
+	return flags.SetAnnotation(name, SourceAnnotation, []string{source.String()})
+}
+
+// SourceOf returns where the value of the named flag came from: the source
+// recorded by Set, SourceFlag for other changed flags, SourceDefault otherwise.
+func SourceOf(flags *pflag.FlagSet, name string) Source {
+	flag := flags.Lookup(name)
+	if flag == nil {
+		return SourceDefault
+	}
+	if values := flag.Annotations[SourceAnnotation]; len(values) > 0 {
+		for s, sourceName := range sourceNames {
+			if values[0] == sourceName {
+				return Source(s)
+			}
+		}
+	}
+	if flag.Changed {
+		return SourceFlag
+	}
+	return SourceDefault
+}
+
+// Sources returns the source of each of the named flags.
+func Sources(flags *pflag.FlagSet, names []string) map[string]Source {
+	sources := make(map[string]Source, len(names))
+	for _, name := range names {
+		sources[name] = SourceOf(flags, name)
+	}
+	return sources
+}
+
+// Redacted replaces the value of sensitive flags in output meant for humans or logs.
+const Redacted = "[REDACTED]"
+
+// PrintEffectiveConfig writes one "name=value (source)" line per named flag to w,
+// skipping names not defined in flags. The values of the redacted flags are
+// printed as Redacted.
+func PrintEffectiveConfig(w io.Writer, flags *pflag.FlagSet, names, redacted []string) error {
+	redact := make(map[string]bool, len(redacted))
+	for _, name := range redacted {
+		redact[name] = true
+	}
+
+	for _, name := range names {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			continue
+		}
+		value := flag.Value.String()
+		if redact[name] {
+			value = Redacted
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s (%s)\n", name, value, SourceOf(flags, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decoder decodes the contents of a config file into its top-level keys and values.
+// Values are strings, bools, numbers or lists of those.
+type Decoder func(data []byte) (map[string]interface{}, error)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]Decoder{".json": decodeJSON, ".yaml": decodeYAML, ".yml": decodeYAML}
+)
+
+// RegisterDecoder registers the decoder for config files with the given
+// extension, e.g. ".toml". JSON (.json) and YAML (.yaml, .yml) files are
+// supported out of the box; registering one of their extensions replaces
+// the built-in decoder.
+func RegisterDecoder(ext string, decoder Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[strings.ToLower(ext)] = decoder
+}
+
+// ApplyConfigFile sets the flags that were not given on the command line or
+// the environment from the config file at path, choosing the decoder by file
+// extension: JSON and YAML are built in, other formats need a decoder
+// registered with RegisterDecoder first. keys maps config file keys to flag
+// names; other keys are ignored.
+func ApplyConfigFile(flags *pflag.FlagSet, path string, keys map[string]string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	decodersMu.RLock()
+	decode, ok := decoders[ext]
+	decodersMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unsupported config file format %q for %s: no decoder is registered for the extension, see flagsrt.RegisterDecoder", ext, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	values, err := decode(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode config file %s: %w", path, err)
+	}
+
+	configKeys := make([]string, 0, len(values))
+	for key := range values {
+		configKeys = append(configKeys, key)
+	}
+	sort.Strings(configKeys)
+
+	for _, key := range configKeys {
+		name, ok := keys[key]
+		if !ok || flags.Changed(name) {
+			continue
+		}
+		if err := setValue(flags, name, values[key]); err != nil {
+			return fmt.Errorf("invalid value for %s in config file %s: %w", key, path, err)
+		}
+	}
+	return nil
+}
+
+// setValue sets the named flag from a decoded config value.
+func setValue(flags *pflag.FlagSet, name string, value interface{}) error {
+	list, isList := value.([]interface{})
+	if !isList {
+		return Set(flags, name, fmt.Sprint(value), SourceConfigFile)
+	}
+
+	elems := make([]string, len(list))
+	for i, elem := range list {
+		elems[i] = fmt.Sprint(elem)
+	}
+	return setSlice(flags, name, elems, SourceConfigFile)
+}
+
+// setSlice replaces the elements of the named slice flag and records source as their origin.
+func setSlice(flags *pflag.FlagSet, name string, elems []string, source Source) error {
+	flag := flags.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %s is not defined", name)
+	}
+	slice, ok := flag.Value.(pflag.SliceValue)
+	if !ok {
+		return fmt.Errorf("flag %s does not accept a list", name)
+	}
+	if err := slice.Replace(elems); err != nil {
+		return err
+	}
+	flag.Changed = true
+	return flags.SetAnnotation(name, SourceAnnotation, []string{source.String()})
+}
+
+// decodeJSON decodes a JSON object, keeping numbers in their original form.
+func decodeJSON(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// decodeYAML decodes a YAML mapping, keeping scalars in their original form.
+// Values of keys that are not flags, such as nested mappings, are decoded as is.
+func decodeYAML(data []byte) (map[string]interface{}, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	if len(document.Content) == 0 {
+		return values, nil
+	}
+	mapping := document.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: config file is not a mapping", mapping.Line)
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		value, err := yamlValue(mapping.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		values[mapping.Content[i].Value] = value
+	}
+	return values, nil
+}
+
+// yamlValue returns the text of a scalar node, the values of a sequence node
+// as a list and any other node decoded into Go values.
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!null" {
+			return nil, nil
+		}
+		return node.Value, nil
+	case yaml.SequenceNode:
+		list := make([]interface{}, len(node.Content))
+		for i, elem := range node.Content {
+			value, err := yamlValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	default:
+		var value interface{}
+		err := node.Decode(&value)
+		return value, err
+	}
+}
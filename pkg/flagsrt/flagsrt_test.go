@@ -0,0 +1,141 @@
+package flagsrt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func newTestFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("host", "localhost", "")
+	flags.Int("port", 8080, "")
+	flags.StringSlice("tags", nil, "")
+	flags.Bool("debug", false, "")
+	return flags
+}
+
+func TestSourceOf(t *testing.T) {
+	flags := newTestFlagSet()
+	if err := flags.Parse([]string{"--debug"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := Set(flags, "host", "example.com", SourceEnv); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	expected := map[string]Source{
+		"host":  SourceEnv,
+		"port":  SourceDefault,
+		"debug": SourceFlag,
+	}
+	sources := Sources(flags, []string{"host", "port", "debug"})
+	for name, source := range expected {
+		if sources[name] != source {
+			t.Errorf("Source of %s = %v, want %v", name, sources[name], source)
+		}
+	}
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"host": "file.example.com", "port": 9090, "tags": ["a", "b"], "debug": false, "unknown": 1}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	flags := newTestFlagSet()
+	if err := flags.Parse([]string{"--debug"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	keys := map[string]string{"host": "host", "port": "port", "tags": "tags", "debug": "debug"}
+	if err := ApplyConfigFile(flags, path, keys); err != nil {
+		t.Fatalf("ApplyConfigFile failed: %v", err)
+	}
+
+	var out strings.Builder
+	if err := PrintEffectiveConfig(&out, flags, []string{"host", "port", "tags", "debug"}, []string{"tags"}); err != nil {
+		t.Fatalf("PrintEffectiveConfig failed: %v", err)
+	}
+	expected := "host=file.example.com (config-file)\n" +
+		"port=9090 (config-file)\n" +
+		"tags=[REDACTED] (config-file)\n" +
+		"debug=true (flag)\n"
+	if out.String() != expected {
+		t.Errorf("PrintEffectiveConfig output:\n%s\nwant:\n%s", out.String(), expected)
+	}
+}
+
+func TestApplyConfigFile_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `# Host is the server host.
+host: file.example.com
+port: 0x2382
+tags:
+  - a
+  - "b,c"
+debug: false
+unknown:
+  nested: 1
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	flags := newTestFlagSet()
+	if err := flags.Parse([]string{"--debug"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	keys := map[string]string{"host": "host", "port": "port", "tags": "tags", "debug": "debug"}
+	if err := ApplyConfigFile(flags, path, keys); err != nil {
+		t.Fatalf("ApplyConfigFile failed: %v", err)
+	}
+
+	var out strings.Builder
+	if err := PrintEffectiveConfig(&out, flags, []string{"host", "port", "tags", "debug"}, nil); err != nil {
+		t.Fatalf("PrintEffectiveConfig failed: %v", err)
+	}
+	expected := "host=file.example.com (config-file)\n" +
+		"port=9090 (config-file)\n" +
+		"tags=[a,\"b,c\"] (config-file)\n" +
+		"debug=true (flag)\n"
+	if out.String() != expected {
+		t.Errorf("PrintEffectiveConfig output:\n%s\nwant:\n%s", out.String(), expected)
+	}
+	if tags, _ := flags.GetStringSlice("tags"); len(tags) != 2 || tags[1] != "b,c" {
+		t.Errorf("tags = %q, expected [a b,c]", tags)
+	}
+}
+
+func TestApplyConfigFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{name: "unsupported format", file: "config.ini", content: "", expected: `unsupported config file format ".ini"`},
+		{name: "malformed", file: "bad.json", content: "{", expected: "failed to decode config file"},
+		{name: "malformed yaml", file: "bad.yaml", content: "host: [", expected: "failed to decode config file"},
+		{name: "yaml list document", file: "list.yml", content: "- host\n", expected: "config file is not a mapping"},
+		{name: "invalid value", file: "port.json", content: `{"port": "high"}`, expected: "invalid value for port"},
+		{name: "list for scalar", file: "list.json", content: `{"host": ["a"]}`, expected: "does not accept a list"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			err := ApplyConfigFile(newTestFlagSet(), path, map[string]string{"host": "host", "port": "port"})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("ApplyConfigFile error = %v, want it to contain %q", err, tt.expected)
+			}
+		})
+	}
+}
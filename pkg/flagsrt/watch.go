@@ -0,0 +1,93 @@
+package flagsrt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// WatchInterval is how often WatchFile checks the watched file for changes.
+var WatchInterval = 2 * time.Second
+
+// ReloadErrorHandler is called when reloading a watched file fails. The previous
+// configuration stays in effect and watching continues. The default handler
+// writes the error to stderr.
+var ReloadErrorHandler = func(path string, err error) {
+	fmt.Fprintf(os.Stderr, "flags-gen: failed to reload %s: %v\n", path, err)
+}
+
+// WatchFile polls the file at path every WatchInterval and calls reload each
+// time its content changes, including when it is replaced through a symlink as
+// in Kubernetes ConfigMap volumes. Errors returned by reload are passed to
+// ReloadErrorHandler. WatchFile blocks until ctx is done and then returns nil.
+func WatchFile(ctx context.Context, path string, reload func() error) error {
+	last, err := readIfExists(path)
+	if err != nil {
+		return fmt.Errorf("failed to read watched file: %w", err)
+	}
+
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		data, err := readIfExists(path)
+		if err != nil {
+			ReloadErrorHandler(path, err)
+			continue
+		}
+		if data == nil || bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+
+		if err := reload(); err != nil {
+			ReloadErrorHandler(path, err)
+		}
+	}
+}
+
+// readIfExists returns the content of the file at path, or nil if it does not exist.
+func readIfExists(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// CopyFlags copies the values of the named flags that were set on the command
+// line or from the environment from src to dst, keeping their source. Together
+// with ApplyConfigFile it rebuilds a FlagSet with the same precedence after the
+// config file changed.
+func CopyFlags(dst, src *pflag.FlagSet, names []string) error {
+	for _, name := range names {
+		source := SourceOf(src, name)
+		if source != SourceFlag && source != SourceEnv {
+			continue
+		}
+
+		flag := src.Lookup(name)
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			if err := setSlice(dst, name, slice.GetSlice(), source); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := Set(dst, name, flag.Value.String(), source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
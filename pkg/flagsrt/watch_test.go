@@ -0,0 +1,85 @@
+package flagsrt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchFile(t *testing.T) {
+	interval := WatchInterval
+	WatchInterval = 5 * time.Millisecond
+	defer func() { WatchInterval = interval }()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"port": 1}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reloads := make(chan struct{}, 1)
+	done := make(chan error)
+	go func() {
+		done <- WatchFile(ctx, path, func() error {
+			reloads <- struct{}{}
+			return nil
+		})
+	}()
+
+	select {
+	case <-reloads:
+		t.Fatal("WatchFile reloaded an unchanged file")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(path, []byte(`{"port": 2}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	select {
+	case <-reloads:
+	case <-time.After(time.Second):
+		t.Fatal("WatchFile did not reload the changed file")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("WatchFile returned %v after cancellation, want nil", err)
+	}
+}
+
+func TestCopyFlags(t *testing.T) {
+	src := newTestFlagSet()
+	if err := src.Parse([]string{"--tags=a,b"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := Set(src, "host", "env.example.com", SourceEnv); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := Set(src, "port", "9090", SourceConfigFile); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	dst := newTestFlagSet()
+	if err := CopyFlags(dst, src, []string{"host", "port", "tags", "debug"}); err != nil {
+		t.Fatalf("CopyFlags failed: %v", err)
+	}
+
+	expected := map[string]struct {
+		value  string
+		source Source
+	}{
+		"host": {"env.example.com", SourceEnv},
+		"port": {"8080", SourceDefault},
+		"tags": {"[a,b]", SourceFlag},
+	}
+	for name, want := range expected {
+		if value := dst.Lookup(name).Value.String(); value != want.value {
+			t.Errorf("Value of %s = %q, want %q", name, value, want.value)
+		}
+		if source := SourceOf(dst, name); source != want.source {
+			t.Errorf("Source of %s = %v, want %v", name, source, want.source)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+// Supported example config formats.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// ConfigFormats lists the formats accepted by GenerateExampleConfig.
+var ConfigFormats = []string{FormatYAML, FormatJSON, FormatTOML}
+
+// GenerateExampleConfig generates a sample config file for a struct in the given
+// format. Every field with a supported flag type becomes a key named after its
+// json tag, set to its default value. YAML and TOML output carries the struct and
+// field doc comments; JSON has no comment syntax, so only keys and values are emitted.
+// Keys of sensitive fields and of fields whose default cannot be written out, as
+// it comes from a Go expression, are commented out in YAML and TOML and left out
+// of JSON, so that a copied sample does not override the default.
+func (g *Generator) GenerateExampleConfig(structInfo *types.StructInfo, format string) (string, error) {
+	var fields []types.FieldInfo
+	for i := range structInfo.Fields {
+		if structInfo.Fields[i].FlagMethod != "" {
+			fields = append(fields, structInfo.Fields[i])
+		}
+	}
+
+	switch format {
+	case FormatYAML:
+		return g.exampleYAML(structInfo, fields), nil
+	case FormatJSON:
+		return g.exampleJSON(fields)
+	case FormatTOML:
+		return g.exampleTOML(structInfo, fields), nil
+	default:
+		return "", fmt.Errorf("unsupported config format %q (supported: %s)", format, strings.Join(ConfigFormats, ", "))
+	}
+}
+
+// exampleYAML renders fields as a commented YAML document.
+func (g *Generator) exampleYAML(structInfo *types.StructInfo, fields []types.FieldInfo) string {
+	var b strings.Builder
+	writeComment(&b, "#", docText(structInfo.Doc, structInfo.Description))
+	for i := range fields {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		writeComment(&b, "#", docText(fields[i].Doc, fields[i].Description))
+		var entry strings.Builder
+		key := configKey(&fields[i])
+		switch v := exampleValue(&fields[i]).(type) {
+		case []interface{}:
+			if len(v) == 0 {
+				fmt.Fprintf(&entry, "%s: []\n", key)
+				break
+			}
+			fmt.Fprintf(&entry, "%s:\n", key)
+			for _, elem := range v {
+				fmt.Fprintf(&entry, "  - %s\n", yamlScalar(elem))
+			}
+		default:
+			fmt.Fprintf(&entry, "%s: %s\n", key, yamlScalar(v))
+		}
+		writeEntry(&b, "#", &fields[i], entry.String())
+	}
+	return b.String()
+}
+
+// exampleTOML renders fields as a commented TOML document.
+func (g *Generator) exampleTOML(structInfo *types.StructInfo, fields []types.FieldInfo) string {
+	var b strings.Builder
+	writeComment(&b, "#", docText(structInfo.Doc, structInfo.Description))
+	for i := range fields {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		writeComment(&b, "#", docText(fields[i].Doc, fields[i].Description))
+		var entry string
+		key := configKey(&fields[i])
+		switch v := exampleValue(&fields[i]).(type) {
+		case []interface{}:
+			elems := make([]string, len(v))
+			for j, elem := range v {
+				elems[j] = tomlScalar(elem)
+			}
+			entry = fmt.Sprintf("%s = [%s]\n", tomlKey(key), strings.Join(elems, ", "))
+		default:
+			entry = fmt.Sprintf("%s = %s\n", tomlKey(key), tomlScalar(v))
+		}
+		writeEntry(&b, "#", &fields[i], entry)
+	}
+	return b.String()
+}
+
+// exampleJSON renders fields as an indented JSON object, preserving field order.
+// Fields whose key would be commented out in YAML are left out.
+func (g *Generator) exampleJSON(allFields []types.FieldInfo) (string, error) {
+	var fields []types.FieldInfo
+	for i := range allFields {
+		if omittedReason(&allFields[i]) == "" {
+			fields = append(fields, allFields[i])
+		}
+	}
+	if len(fields) == 0 {
+		return "{}\n", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	for i := range fields {
+		key, err := json.Marshal(configKey(&fields[i]))
+		if err != nil {
+			return "", fmt.Errorf("failed to encode key for field %s: %w", fields[i].Name, err)
+		}
+		value, err := json.Marshal(exampleValue(&fields[i]))
+		if err != nil {
+			return "", fmt.Errorf("failed to encode default for field %s: %w", fields[i].Name, err)
+		}
+		fmt.Fprintf(&b, "  %s: %s", key, value)
+		if i < len(fields)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// configKey returns the config file key for a field, following encoding/json naming.
+func configKey(field *types.FieldInfo) string {
+	if field.JSONTag != "" {
+		return field.JSONTag
+	}
+	return field.Name
+}
+
+// exampleValue normalizes a field's default value into a string, bool, json.Number
+// or []interface{} of those, falling back to the zero value for the field type.
+// Sensitive fields always get the zero value so their default is not published,
+// even in their commented-out key.
+func exampleValue(field *types.FieldInfo) interface{} {
+	value := field.DefaultValue
+	if field.Sensitive {
+		value = nil
+	}
+
+	switch field.Type {
+	case types.TypeString:
+		if value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	case types.TypeTimeDuration:
+		if value == nil {
+			return "0s"
+		}
+		return fmt.Sprint(value)
+	case types.TypeBool:
+		if b, ok := value.(bool); ok {
+			return b
+		}
+		return false
+	case types.TypeStringSlice:
+		elems := []interface{}{}
+		for _, s := range defaultElements(value) {
+			elems = append(elems, s)
+		}
+		return elems
+	case "[]int":
+		elems := []interface{}{}
+		for _, s := range defaultElements(value) {
+			elems = append(elems, numberValue(s))
+		}
+		return elems
+	default:
+		if value == nil {
+			return json.Number("0")
+		}
+		return numberValue(fmt.Sprint(value))
+	}
+}
+
+// defaultElements returns the elements of a slice default value.
+func defaultElements(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []int:
+		elems := make([]string, len(v))
+		for i, n := range v {
+			elems[i] = strconv.Itoa(n)
+		}
+		return elems
+	case string:
+		if v == "" {
+			return nil
+		}
+		return strings.Split(v, ",")
+	default:
+		return nil
+	}
+}
+
+// numberValue returns s as a json.Number, or zero when s is not numeric.
+func numberValue(s string) json.Number {
+	s = strings.TrimSpace(s)
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return json.Number("0")
+	}
+	return json.Number(s)
+}
+
+// yamlScalar formats a scalar value for YAML. Go-quoted strings are valid
+// YAML double-quoted scalars.
+func yamlScalar(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
+
+// tomlScalar formats a scalar value for TOML.
+func tomlScalar(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return tomlQuote(s)
+	}
+	return fmt.Sprint(value)
+}
+
+// tomlKey returns key as a bare TOML key when possible, quoted otherwise.
+func tomlKey(key string) string {
+	for _, r := range key {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return tomlQuote(key)
+		}
+	}
+	return key
+}
+
+// tomlQuote quotes s as a TOML basic string.
+func tomlQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// docText returns the full doc comment of a field or struct for comments in
+// example configs, or its description when it has no doc comment.
+func docText(doc, description string) string {
+	if doc != "" {
+		return doc
+	}
+	return description
+}
+
+// omittedReason returns why the key of a field is commented out of example
+// configs, or "" when its default value is written.
+func omittedReason(field *types.FieldInfo) string {
+	if field.Sensitive {
+		return "Sensitive, its default is not published."
+	}
+	if field.DefaultExpr != "" {
+		return "Defaults to the Go expression " + field.DefaultExpr + "."
+	}
+	return ""
+}
+
+// writeEntry writes the key and value lines of a field, commented out with the
+// reason from omittedReason when the field has one.
+func writeEntry(b *strings.Builder, prefix string, field *types.FieldInfo, entry string) {
+	reason := omittedReason(field)
+	if reason == "" {
+		b.WriteString(entry)
+		return
+	}
+	writeComment(b, prefix, reason)
+	writeComment(b, prefix, strings.TrimSuffix(entry, "\n"))
+}
+
+// writeComment writes text as line comments using the given comment prefix.
+func writeComment(b *strings.Builder, prefix, text string) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			b.WriteString(prefix + "\n")
+			continue
+		}
+		b.WriteString(prefix + " " + line + "\n")
+	}
+}
@@ -6,9 +6,14 @@ package generator
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/parser"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/yuvalwz/flags-gen/pkg/types"
 )
@@ -28,16 +33,32 @@ func New() *Generator {
 
 // GenerateFlags generates the AddFlags method for a struct.
 func (g *Generator) GenerateFlags(structInfo *types.StructInfo) (string, error) {
-	var buf bytes.Buffer
+	return g.GenerateFile([]types.StructInfo{*structInfo})
+}
 
-	data := struct {
-		StructInfo *types.StructInfo
-		HasImports bool
-	}{
-		StructInfo: structInfo,
-		HasImports: len(structInfo.Imports) > 0,
+// GenerateFile generates the flags code for several structs of one package as
+// a single Go file, with one package clause and the imports of all of them.
+func (g *Generator) GenerateFile(structs []types.StructInfo) (string, error) {
+	if len(structs) == 0 {
+		return "", fmt.Errorf("no structs to generate flags for")
+	}
+
+	data := fileData{PackageName: structs[0].PackageName}
+	var imports, externalImports []string
+	for i := range structs {
+		if structs[i].PackageName != data.PackageName {
+			return "", fmt.Errorf("struct %s is in package %s, not %s; structs of different packages need separate files",
+				structs[i].Name, structs[i].PackageName, data.PackageName)
+		}
+		structData, structImports, structExternalImports := newFlagsData(&structs[i])
+		data.Structs = append(data.Structs, structData)
+		imports = append(imports, structImports...)
+		externalImports = append(externalImports, structExternalImports...)
 	}
+	data.Imports = uniqueSorted(imports)
+	data.ExternalImports = uniqueSorted(externalImports)
 
+	var buf bytes.Buffer
 	if err := g.template.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("failed to execute template: %w", err)
 	}
@@ -51,6 +72,91 @@ func (g *Generator) GenerateFlags(structInfo *types.StructInfo) (string, error)
 	return string(formatted), nil
 }
 
+// newFlagsData returns the template data for a struct, with the standard
+// library and the external imports its generated code needs.
+func newFlagsData(structInfo *types.StructInfo) (data flagsData, imports, externalImports []string) {
+	methodName := structInfo.MethodName
+	if methodName == "" {
+		methodName = types.DefaultMethodName
+	}
+
+	hasEnv := false
+	for i := range structInfo.Fields {
+		if structInfo.Fields[i].FlagMethod != "" && structInfo.Fields[i].EnvVar != "" {
+			hasEnv = true
+		}
+	}
+
+	groups := flagGroups(structInfo)
+
+	for _, imp := range structInfo.Imports {
+		if imp == "time" && !usesTime(structInfo) {
+			continue
+		}
+		if isExternalImport(imp) {
+			externalImports = append(externalImports, imp)
+		} else {
+			imports = append(imports, imp)
+		}
+	}
+	if hasEnv {
+		imports = append(imports, "fmt", "os")
+	}
+	if len(groups) > 0 {
+		imports = append(imports, "fmt", "strings")
+	}
+	if hasEnv && structInfo.WithPrefix {
+		imports = append(imports, "strings")
+	}
+	if structInfo.HasFromFile() {
+		imports = append(imports, "fmt", "os", "strings")
+	}
+	if structInfo.Watch {
+		imports = append(imports, "context", "fmt")
+	}
+
+	externalImports = append(externalImports, "github.com/spf13/pflag")
+	if structInfo.HasConstraints() || structInfo.HasCompletions() {
+		externalImports = append(externalImports, "github.com/spf13/cobra")
+	}
+	if structInfo.UsesRuntime() {
+		externalImports = append(externalImports, runtimeImport)
+	}
+	if structInfo.Provenance {
+		imports = append(imports, "io")
+	}
+	if structInfo.HasSensitive() {
+		imports = append(imports, "encoding/json", "fmt")
+	}
+	if structInfo.HasValidation() {
+		imports = append(imports, "errors")
+		for i := range structInfo.Fields {
+			validation := structInfo.Fields[i].Validation
+			if structInfo.Fields[i].FlagMethod == "" || validation == nil {
+				continue
+			}
+			if !structInfo.Fields[i].Sensitive {
+				// Invalid values are reported with fmt.Errorf
+				imports = append(imports, "fmt")
+			}
+			if validation.MinLength != nil || validation.MaxLength != nil {
+				imports = append(imports, "unicode/utf8")
+			}
+			if validation.Pattern != "" {
+				imports = append(imports, "regexp")
+			}
+		}
+	}
+
+	data = flagsData{
+		StructInfo: structInfo,
+		MethodName: methodName,
+		HasEnv:     hasEnv,
+		Groups:     groups,
+	}
+	return data, imports, externalImports
+}
+
 // GenerateFlag generates a single flag declaration.
 func (g *Generator) GenerateFlag(field *types.FieldInfo) (string, error) {
 	method, exists := types.GetFlagMethod(field.Type)
@@ -102,7 +208,19 @@ func (g *Generator) formatDefaultValue(value interface{}, fieldType string) stri
 			return fmt.Sprintf("[]string{%s}", strings.Join(quoted, ", "))
 		}
 		return `[]string{}`
+	case "[]int":
+		if slice, ok := value.([]int); ok {
+			elems := make([]string, len(slice))
+			for i, n := range slice {
+				elems[i] = strconv.Itoa(n)
+			}
+			return fmt.Sprintf("[]int{%s}", strings.Join(elems, ", "))
+		}
+		return `[]int{}`
 	case types.TypeTimeDuration:
+		if d, ok := value.(time.Duration); ok {
+			return types.DurationCode(d)
+		}
 		return fmt.Sprintf("%v", value)
 	default:
 		return fmt.Sprintf("%v", value)
@@ -111,6 +229,11 @@ func (g *Generator) formatDefaultValue(value interface{}, fieldType string) stri
 
 // getZeroValue returns the zero value for a given type.
 func (g *Generator) getZeroValue(fieldType string) string {
+	return zeroValueCode(fieldType)
+}
+
+// zeroValueCode returns the Go expression for the zero value of a field type.
+func zeroValueCode(fieldType string) string {
 	switch fieldType {
 	case types.TypeString:
 		return `""`
@@ -127,26 +250,749 @@ func (g *Generator) getZeroValue(fieldType string) string {
 	}
 }
 
+// defaultGroupName is the heading for flags without a +flags-gen:group marker.
+const defaultGroupName = "Flags"
+
+// runtimeImport is the import path of the runtime support package used by generated code.
+const runtimeImport = "github.com/yuvalwz/flags-gen/pkg/flagsrt"
+
+// usesTime reports whether the code generated for structInfo refers to the
+// time package: a duration field with a non-zero default, a default
+// expression selecting from time, or a time-typed field that MarshalLogJSON
+// declares. A duration field without a default is registered with 0.
+func usesTime(structInfo *types.StructInfo) bool {
+	for i := range structInfo.Fields {
+		field := &structInfo.Fields[i]
+		if field.FlagMethod == "" {
+			continue
+		}
+		if field.DefaultExpr != "" {
+			if selectsTime(field.DefaultExpr) {
+				return true
+			}
+		} else if field.Type == types.TypeTimeDuration && field.DefaultValueCode != "" && field.DefaultValueCode != "0" {
+			return true
+		}
+		// MarshalLogJSON declares the fields with their types
+		if structInfo.HasSensitive() && !field.Sensitive && strings.HasPrefix(field.Type, "time.") {
+			return true
+		}
+	}
+	return false
+}
+
+// selectsTime reports whether the Go expression expr selects a member of the
+// time package, as in time.Minute.
+func selectsTime(expr string) bool {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return false
+	}
+	found := false
+	ast.Inspect(parsed, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == "time" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// fileData is the data passed to flagsTemplate: the package and imports of
+// the generated file and the structs it generates code for.
+type fileData struct {
+	PackageName     string
+	Imports         []string
+	ExternalImports []string
+	Structs         []flagsData
+}
+
+// ImportSpec returns the import spec for an import of StructInfo.Imports,
+// which is a path optionally preceded by a package name and a space.
+func (d fileData) ImportSpec(imp string) string {
+	if name, importPath, ok := strings.Cut(imp, " "); ok {
+		return name + " " + strconv.Quote(importPath)
+	}
+	return strconv.Quote(imp)
+}
+
+// flagsData is the data passed to the struct template for each struct.
+type flagsData struct {
+	StructInfo *types.StructInfo
+	MethodName string
+	HasEnv     bool
+	Groups     []flagGroup
+}
+
+// Quote returns s as a Go string literal. Every string from the input, such as
+// a description taken from a doc comment, reaches the generated code through
+// Quote so quotes, backslashes and newlines in it cannot break the output.
+func (d flagsData) Quote(s string) string {
+	return strconv.Quote(s)
+}
+
+// QuoteList returns the comma-separated Go string literals for a list of strings.
+func (d flagsData) QuoteList(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = strconv.Quote(s)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// Flag returns the Go expression for a flag name, prepending the runtime
+// prefix parameter when the struct has WithPrefix variants.
+func (d flagsData) Flag(name string) string {
+	if d.StructInfo.WithPrefix {
+		return "prefix + " + strconv.Quote(name)
+	}
+	return strconv.Quote(name)
+}
+
+// Flags returns the comma-separated Go expressions for a list of flag names.
+func (d flagsData) Flags(names []string) string {
+	exprs := make([]string, len(names))
+	for i, name := range names {
+		exprs[i] = d.Flag(name)
+	}
+	return strings.Join(exprs, ", ")
+}
+
+// FlagNames returns the names of the struct's flags in declaration order, without aliases.
+func (d flagsData) FlagNames() []string {
+	var names []string
+	for i := range d.StructInfo.Fields {
+		if d.StructInfo.Fields[i].FlagMethod != "" {
+			names = append(names, d.StructInfo.Fields[i].FlagName)
+		}
+	}
+	return names
+}
+
+// ConfigKey returns the config file key of a field.
+func (d flagsData) ConfigKey(field types.FieldInfo) string {
+	return configKey(&field)
+}
+
+// DefaultCode returns the default value expression a field's flag is registered
+// with. Sensitive fields are registered with the zero value so their default
+// stays out of --help, and get their default assigned afterwards.
+func (d flagsData) DefaultCode(field types.FieldInfo) string {
+	if field.Sensitive {
+		return zeroValueCode(field.Type)
+	}
+	return field.DefaultValueCode
+}
+
+// SensitiveNames returns the Go expression for the list of the struct's
+// sensitive flag names, or nil when there are none.
+func (d flagsData) SensitiveNames() string {
+	var names []string
+	for i := range d.StructInfo.Fields {
+		if d.StructInfo.Fields[i].FlagMethod != "" && d.StructInfo.Fields[i].Sensitive {
+			names = append(names, d.StructInfo.Fields[i].FlagName)
+		}
+	}
+	if len(names) == 0 {
+		return "nil"
+	}
+	return "[]string{" + d.Flags(names) + "}"
+}
+
+// StringFormat returns the fmt format for the generated String method, in the
+// style of %+v with the values of sensitive fields replaced by [REDACTED].
+func (d flagsData) StringFormat() string {
+	var fields []string
+	for i := range d.StructInfo.Fields {
+		field := &d.StructInfo.Fields[i]
+		if field.FlagMethod == "" {
+			continue
+		}
+		if field.Sensitive {
+			fields = append(fields, field.Name+":"+redacted)
+		} else {
+			fields = append(fields, field.Name+":%v")
+		}
+	}
+	return d.StructInfo.Name + "{" + strings.Join(fields, " ") + "}"
+}
+
+// Redacted returns the placeholder for sensitive values.
+func (d flagsData) Redacted() string {
+	return redacted
+}
+
+// JSONTag returns the struct tag literal giving a field its config key in the
+// generated MarshalLogJSON method. The tag is a raw string literal unless the
+// key contains a backquote.
+func (d flagsData) JSONTag(field types.FieldInfo) string {
+	tag := "json:" + strconv.Quote(configKey(&field))
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// Env returns the Go expression for an environment variable name, prepending
+// the environment form of the runtime prefix when the struct has WithPrefix variants.
+func (d flagsData) Env(name string) string {
+	if d.StructInfo.WithPrefix {
+		return "envPrefix + " + strconv.Quote(name)
+	}
+	return strconv.Quote(name)
+}
+
+// Set returns the Go expression setting the named flag to the value expression.
+// When the struct uses the flagsrt runtime, the source expression is recorded too.
+func (d flagsData) Set(name, value, source string) string {
+	if d.StructInfo.UsesRuntime() {
+		return fmt.Sprintf("flagsrt.Set(flags, %s, %s, %s)", d.Flag(name), value, source)
+	}
+	return fmt.Sprintf("flags.Set(%s, %s)", d.Flag(name), value)
+}
+
+// Call returns the call of a generated method with the given arguments, passing
+// the runtime prefix on to the WithPrefix variant when the struct has them.
+func (d flagsData) Call(name, args string) string {
+	if d.StructInfo.WithPrefix {
+		if args == "" {
+			return name + "WithPrefix(prefix)"
+		}
+		return name + "WithPrefix(" + args + ", prefix)"
+	}
+	return name + "(" + args + ")"
+}
+
+// FlagMessage returns the Go expression for a message mentioning a flag, formatted
+// from format with a single %s standing for the flag name and %% for a percent
+// sign. With WithPrefix, the runtime prefix is inserted before the name.
+func (d flagsData) FlagMessage(format, name string) string {
+	if d.StructInfo.WithPrefix {
+		before, after, _ := strings.Cut(format, "%s")
+		unescape := strings.NewReplacer("%%", "%")
+		return strconv.Quote(unescape.Replace(before)) + " + prefix + " + strconv.Quote(name+unescape.Replace(after))
+	}
+	return strconv.Quote(fmt.Sprintf(format, name))
+}
+
+// Method describes a generated method that refers to flags by name, for the
+// "signature" template. params and args are the method parameters without the
+// prefix, doc continues the sentence "// <name> ...".
+func (d flagsData) Method(name, params, args, results, doc string) flagsMethod {
+	return flagsMethod{
+		Receiver:   d.StructInfo.Name,
+		Name:       name,
+		Params:     params,
+		Args:       args,
+		Results:    results,
+		Doc:        doc,
+		WithPrefix: d.StructInfo.WithPrefix,
+		Registers:  name == d.MethodName,
+	}
+}
+
+// validationCheck is a check of a field value in the generated ValidateFlags
+// method: the value is invalid when Cond holds.
+type validationCheck struct {
+	Cond string
+	// Message is the Go expression for the error message.
+	Message string
+	// Got is the Go expression for the invalid value reported with the message,
+	// or "" for sensitive fields.
+	Got string
+}
+
+// ValidationChecks returns the checks of the Validation of a field.
+func (d flagsData) ValidationChecks(field types.FieldInfo) []validationCheck {
+	v := field.Validation
+	if v == nil {
+		return nil
+	}
+
+	value := "o." + field.Name
+	var checks []validationCheck
+	add := func(cond, format, got string) {
+		check := validationCheck{Cond: cond, Message: d.FlagMessage(format, field.FlagName), Got: got}
+		if field.Sensitive {
+			check.Got = ""
+		}
+		checks = append(checks, check)
+	}
+	escape := strings.NewReplacer("%", "%%")
+
+	if v.Minimum != "" {
+		if v.ExclusiveMinimum {
+			add(value+" <= "+v.Minimum, "--%s must be greater than "+v.Minimum, value)
+		} else {
+			add(value+" < "+v.Minimum, "--%s must be at least "+v.Minimum, value)
+		}
+	}
+	if v.Maximum != "" {
+		if v.ExclusiveMaximum {
+			add(value+" >= "+v.Maximum, "--%s must be less than "+v.Maximum, value)
+		} else {
+			add(value+" > "+v.Maximum, "--%s must be at most "+v.Maximum, value)
+		}
+	}
+	length := "utf8.RuneCountInString(" + value + ")"
+	if v.MinLength != nil {
+		add(fmt.Sprintf("%s < %d", length, *v.MinLength), fmt.Sprintf("--%%s must be at least %d characters long", *v.MinLength), value)
+	}
+	if v.MaxLength != nil {
+		add(fmt.Sprintf("%s > %d", length, *v.MaxLength), fmt.Sprintf("--%%s must be at most %d characters long", *v.MaxLength), value)
+	}
+	if v.Pattern != "" {
+		add("!"+d.PatternVar(field)+".MatchString("+value+")", "--%s must match the pattern "+escape.Replace(v.Pattern), value)
+	}
+	if len(v.Enum) > 0 {
+		conds := make([]string, len(v.Enum))
+		for i, elem := range v.Enum {
+			conds[i] = value + " != " + elem
+		}
+		add(strings.Join(conds, " && "), "--%s must be one of "+escape.Replace(strings.Join(v.Enum, ", ")), value)
+	}
+	if v.MinItems != nil {
+		add(fmt.Sprintf("len(%s) < %d", value, *v.MinItems), fmt.Sprintf("--%%s must have at least %d items", *v.MinItems), value)
+	}
+	if v.MaxItems != nil {
+		add(fmt.Sprintf("len(%s) > %d", value, *v.MaxItems), fmt.Sprintf("--%%s must have at most %d items", *v.MaxItems), value)
+	}
+	return checks
+}
+
+// PatternVar returns the name of the package variable holding the compiled
+// validation pattern of a field.
+func (d flagsData) PatternVar(field types.FieldInfo) string {
+	name := d.StructInfo.Name
+	return strings.ToLower(name[:1]) + name[1:] + field.Name + "Pattern"
+}
+
+// redacted replaces the values of sensitive fields, matching flagsrt.Redacted.
+const redacted = "[REDACTED]"
+
+// flagsMethod is the signature of a generated method. With WithPrefix, the
+// method delegates to a <Name>WithPrefix variant taking an extra prefix parameter.
+// Registers marks the method that registers the flags, named after MethodName.
+type flagsMethod struct {
+	Receiver   string
+	Name       string
+	Params     string
+	Args       string
+	Results    string
+	Doc        string
+	WithPrefix bool
+	Registers  bool
+}
+
+// flagGroup is a help section listing flag names in declaration order.
+type flagGroup struct {
+	Name  string
+	Flags []string
+}
+
+// flagGroups splits the flags of a struct into help sections ordered by first
+// appearance. It returns nil when no field has a group, so no grouped usage is generated.
+func flagGroups(structInfo *types.StructInfo) []flagGroup {
+	var groups []flagGroup
+	index := make(map[string]int)
+	grouped := false
+
+	for i := range structInfo.Fields {
+		field := &structInfo.Fields[i]
+		if field.FlagMethod == "" {
+			continue
+		}
+
+		name := field.Group
+		if name == "" {
+			name = defaultGroupName
+		} else {
+			grouped = true
+		}
+
+		if _, ok := index[name]; !ok {
+			index[name] = len(groups)
+			groups = append(groups, flagGroup{Name: name})
+		}
+		groups[index[name]].Flags = append(groups[index[name]].Flags, field.FlagName)
+		if field.FromFile {
+			groups[index[name]].Flags = append(groups[index[name]].Flags, field.FileFlagName())
+		}
+	}
+
+	if !grouped {
+		return nil
+	}
+	return groups
+}
+
+// isExternalImport reports whether imp is outside the standard library, whose
+// import paths have no dot in their first element.
+func isExternalImport(imp string) bool {
+	if _, importPath, ok := strings.Cut(imp, " "); ok {
+		imp = importPath
+	}
+	first, _, _ := strings.Cut(imp, "/")
+	return strings.Contains(first, ".")
+}
+
+// uniqueSorted returns the distinct values of s in sorted order.
+func uniqueSorted(s []string) []string {
+	seen := make(map[string]bool, len(s))
+	result := make([]string, 0, len(s))
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
 // flagsTemplate is the template for generating the AddFlags method.
 const flagsTemplate = `// Code generated by flags-gen. DO NOT EDIT.
 
-package {{.StructInfo.PackageName}}
+package {{.PackageName}}
 
-{{if .HasImports}}
+{{if or .Imports (gt (len .ExternalImports) 1)}}
 import (
-{{range .StructInfo.Imports}}	"{{.}}"
-{{end}}	"github.com/spf13/pflag"
-)
+{{range .Imports}}	{{$.ImportSpec .}}
+{{end}}{{if .Imports}}
+{{end}}{{range .ExternalImports}}	{{$.ImportSpec .}}
+{{end}})
 {{else}}
 import "github.com/spf13/pflag"
 {{end}}
+{{- range .Structs}}
+{{template "struct" .}}
+{{- end}}
+{{- define "struct"}}
+{{template "signature" .Method .MethodName "flags *pflag.FlagSet" "flags" "" (printf "adds all the flags from %s to the given FlagSet" .StructInfo.Name)}}
+{{- if .StructInfo.HasAliases}}
+	// Aliases are normalized to the flag they stand for, so values given under
+	// an old name take the same precedence as the flag itself
+	normalize := flags.GetNormalizeFunc()
+	aliases := map[string]string{
+{{- range .StructInfo.Fields}}
+{{- if .FlagMethod}}
+{{- $field := .}}
+{{- range .Aliases}}
+		{{$.Flag .}}: {{$.Flag $field.FlagName}},
+{{- end}}
+{{- end}}
+{{- end}}
+	}
+	flags.SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		normalized := normalize(f, name)
+		if primary, ok := aliases[string(normalized)]; ok {
+			return normalize(f, primary)
+		}
+		return normalized
+	})
+{{- end}}
+{{- range .StructInfo.Fields}}
+{{- if .FlagMethod}}
+{{- if and .ShortFlag $.StructInfo.WithPrefix}}
+	if prefix == "" {
+		flags.{{.FlagMethod}}P(&o.{{.Name}}, {{$.Quote .FlagName}}, {{$.Quote .ShortFlag}}, {{$.DefaultCode .}}, {{$.Quote .Description}})
+	} else {
+		flags.{{.FlagMethod}}(&o.{{.Name}}, {{$.Flag .FlagName}}, {{$.DefaultCode .}}, {{$.Quote .Description}})
+	}
+{{- else if .ShortFlag}}
+	flags.{{.FlagMethod}}P(&o.{{.Name}}, {{$.Flag .FlagName}}, {{$.Quote .ShortFlag}}, {{$.DefaultCode .}}, {{$.Quote .Description}})
+{{- else}}
+	flags.{{.FlagMethod}}(&o.{{.Name}}, {{$.Flag .FlagName}}, {{$.DefaultCode .}}, {{$.Quote .Description}})
+{{- end}}
+{{- if .Hidden}}
+	_ = flags.MarkHidden({{$.Flag .FlagName}})
+{{- end}}
+{{- if .Deprecated}}
+	_ = flags.MarkDeprecated({{$.Flag .FlagName}}, {{$.Quote .Deprecated}})
+{{- end}}
+{{- if and .ShorthandDeprecated $.StructInfo.WithPrefix}}
+	if prefix == "" {
+		_ = flags.MarkShorthandDeprecated({{$.Quote .FlagName}}, {{$.Quote .ShorthandDeprecated}})
+	}
+{{- else if .ShorthandDeprecated}}
+	_ = flags.MarkShorthandDeprecated({{$.Flag .FlagName}}, {{$.Quote .ShorthandDeprecated}})
+{{- end}}
+{{- if and .Sensitive (or .DefaultValue .DefaultExpr)}}
+	o.{{.Name}} = {{.DefaultValueCode}}
+{{- end}}
+{{- if .FromFile}}
+	flags.String({{$.Flag .FileFlagName}}, "", {{$.FlagMessage "Read --%s from the file at this path" .FlagName}})
+{{- if .Hidden}}
+	_ = flags.MarkHidden({{$.Flag .FileFlagName}})
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+}
+{{- if .HasEnv}}
 
-// AddFlags adds all the flags from {{.StructInfo.Name}} to the given FlagSet
-func (o *{{.StructInfo.Name}}) AddFlags(flags *pflag.FlagSet) {
+{{template "signature" .Method "ApplyEnv" "flags *pflag.FlagSet" "flags" "error" (printf "sets flags from %s that were not given on the command line\n// from their environment variables. Call it after the FlagSet has been parsed." .StructInfo.Name)}}
+{{- if .StructInfo.WithPrefix}}
+	envPrefix := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(prefix))
+{{- end}}
+{{- range .StructInfo.Fields}}
+{{- if and .FlagMethod .EnvVar}}
+{{- $unset := printf "!flags.Changed(%s)" ($.Flag .FlagName)}}
+{{- if .FromFile}}{{$unset = printf "%s && !flags.Changed(%s)" $unset ($.Flag .FileFlagName)}}{{end}}
+	if value, ok := os.LookupEnv({{$.Env .EnvVar}}); ok && {{$unset}} {
+		if err := {{$.Set .FlagName "value" "flagsrt.SourceEnv"}}; err != nil {
+{{- if .Sensitive}}
+			return fmt.Errorf("invalid value for environment variable %s: %w", {{$.Env .EnvVar}}, err)
+{{- else}}
+			return fmt.Errorf("invalid value %q for environment variable %s: %w", value, {{$.Env .EnvVar}}, err)
+{{- end}}
+		}
+	}
+{{- if .FromFile}}
+	if value, ok := os.LookupEnv({{$.Env .FileEnvVar}}); ok && {{$unset}} {
+		if err := {{$.Set .FileFlagName "value" "flagsrt.SourceEnv"}}; err != nil {
+			return fmt.Errorf("invalid value %q for environment variable %s: %w", value, {{$.Env .FileEnvVar}}, err)
+		}
+	}
+{{- end}}
+{{- end}}
+{{- end}}
+	return nil
+}
+{{- end}}
+{{- if .StructInfo.HasFromFile}}
+
+{{template "signature" .Method "ReadFileFlags" "flags *pflag.FlagSet" "flags" "error" (printf "sets the flags from %s that were given a file with their -file flag\n// to the file content with surrounding whitespace trimmed. Call it once after parsing and\n// applying the environment, before any config file overlay." .StructInfo.Name)}}
+{{- range .StructInfo.Fields}}
+{{- if and .FlagMethod .FromFile}}
+	if flags.Changed({{$.Flag .FileFlagName}}) {
+		if flags.Changed({{$.Flag .FlagName}}) {
+			return fmt.Errorf("--%s and --%s cannot both be set", {{$.Flag .FlagName}}, {{$.Flag .FileFlagName}})
+		}
+		path := flags.Lookup({{$.Flag .FileFlagName}}).Value.String()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read --%s: %w", {{$.Flag .FileFlagName}}, err)
+		}
+		if err := {{$.Set .FlagName "strings.TrimSpace(string(data))" (printf "flagsrt.SourceOf(flags, %s)" ($.Flag .FileFlagName))}}; err != nil {
+			return fmt.Errorf("invalid value in %s for --%s: %w", path, {{$.Flag .FlagName}}, err)
+		}
+	}
+{{- end}}
+{{- end}}
+	return nil
+}
+{{- end}}
+{{- if or .StructInfo.ConfigFile .StructInfo.Watch}}
+
+{{template "signature" .Method "ApplyConfigFile" "flags *pflag.FlagSet, path string" "flags, path" "error" (printf "sets flags from %s that were not given on the command line or the\n// environment from the config file at path, keyed by json name. JSON and YAML\n// files are read out of the box; other formats need a decoder registered with\n// flagsrt.RegisterDecoder. Call it after the FlagSet has been parsed and the environment applied." .StructInfo.Name)}}
+	return flagsrt.ApplyConfigFile(flags, path, map[string]string{
 {{- range .StructInfo.Fields}}
 {{- if .FlagMethod}}
-	flags.{{.FlagMethod}}(&o.{{.Name}}, "{{.FlagName}}", {{.DefaultValueCode}}, "{{.Description}}")
+		{{$.Quote ($.ConfigKey .)}}: {{$.Flag .FlagName}},
 {{- end}}
 {{- end}}
+	})
 }
+{{- end}}
+{{- if .StructInfo.HasValidation}}
+{{- range .StructInfo.Fields}}
+{{- if and .FlagMethod .Validation .Validation.Pattern}}
+
+var {{$.PatternVar .}} = regexp.MustCompile({{$.Quote .Validation.Pattern}})
+{{- end}}
+{{- end}}
+
+{{template "signature" .Method "ValidateFlags" "" "" "error" (printf "checks the values of %s against the constraints of its\n// +kubebuilder:validation markers, returning an error describing every violation." .StructInfo.Name)}}
+	var errs []error
+{{- range .StructInfo.Fields}}
+{{- if .FlagMethod}}
+{{- range $.ValidationChecks .}}
+	if {{.Cond}} {
+{{- if .Got}}
+		errs = append(errs, fmt.Errorf("%s, got %v", {{.Message}}, {{.Got}}))
+{{- else}}
+		errs = append(errs, errors.New({{.Message}}))
+{{- end}}
+	}
+{{- end}}
+{{- end}}
+{{- end}}
+	return errors.Join(errs...)
+}
+{{- end}}
+{{- if .StructInfo.Watch}}
+
+{{template "signature" .Method "Watch" (printf "ctx context.Context, flags *pflag.FlagSet, path string, onChange func(old, new *%s)" .StructInfo.Name) "ctx, flags, path, onChange" "error" (printf "reloads %s whenever the content of the config file at path changes,\n// until ctx is done. Each reload copies o, so fields without a flag keep their values, and\n// sets its flags from their defaults, the config file and the values given on the command\n// line or the environment in the parsed flags. It then checks\n// it with its ValidateFlags and Validate methods if it has them, and only then passes it to\n// onChange with the previous one. Reload errors go to flagsrt.ReloadErrorHandler; o itself is never modified." .StructInfo.Name)}}
+	old := new({{.StructInfo.Name}})
+	*old = *o
+	return flagsrt.WatchFile(ctx, path, func() error {
+		// Registering the flags resets every flag field to its default
+		next := new({{.StructInfo.Name}})
+		*next = *o
+		set := pflag.NewFlagSet(flags.Name(), pflag.ContinueOnError)
+		next.{{.Call .MethodName "set"}}
+		if err := flagsrt.CopyFlags(set, flags, []string{ {{- .Flags .FlagNames}}}); err != nil {
+			return err
+		}
+		if err := next.{{.Call "ApplyConfigFile" "set, path"}}; err != nil {
+			return err
+		}
+{{- if .StructInfo.HasValidation}}
+		if err := next.{{.Call "ValidateFlags" ""}}; err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+{{- end}}
+		if validator, ok := interface{}(next).(interface{ Validate() error }); ok {
+			if err := validator.Validate(); err != nil {
+				return fmt.Errorf("invalid config: %w", err)
+			}
+		}
+		onChange(old, next)
+		old = next
+		return nil
+	})
+}
+{{- end}}
+{{- if .StructInfo.Provenance}}
+
+{{template "signature" .Method "Sources" "flags *pflag.FlagSet" "flags" "map[string]flagsrt.Source" (printf "returns where the value of each flag from %s came from, keyed by flag name.\n// Call it after the FlagSet has been parsed and the env and config file overlays applied." .StructInfo.Name)}}
+	return flagsrt.Sources(flags, []string{ {{- .Flags .FlagNames}}})
+}
+
+{{template "signature" .Method "PrintEffectiveConfig" "w io.Writer, flags *pflag.FlagSet" "w, flags" "error" (printf "writes the value of each flag from %s to w, annotated with its source." .StructInfo.Name)}}
+	return flagsrt.PrintEffectiveConfig(w, flags, []string{ {{- .Flags .FlagNames}}}, {{.SensitiveNames}})
+}
+{{- end}}
+{{- if .StructInfo.HasSensitive}}
+
+// String returns {{.StructInfo.Name}} formatted like %+v, with sensitive values redacted.
+func (o {{.StructInfo.Name}}) String() string {
+	return fmt.Sprintf({{$.Quote .StringFormat}}
+{{- range .StructInfo.Fields}}
+{{- if and .FlagMethod (not .Sensitive)}}, o.{{.Name}}{{end}}
+{{- end}})
+}
+
+// MarshalLogJSON returns {{.StructInfo.Name}} encoded as JSON for logging, keyed by json name,
+// with sensitive values redacted.
+func (o {{.StructInfo.Name}}) MarshalLogJSON() ([]byte, error) {
+	return json.Marshal(struct {
+{{- range .StructInfo.Fields}}
+{{- if .FlagMethod}}
+		{{.Name}} {{if .Sensitive}}string{{else}}{{.Type}}{{end}} {{$.JSONTag .}}
+{{- end}}
+{{- end}}
+	}{
+{{- range .StructInfo.Fields}}
+{{- if .FlagMethod}}
+		{{.Name}}: {{if .Sensitive}}{{$.Quote $.Redacted}}{{else}}o.{{.Name}}{{end}},
+{{- end}}
+{{- end}}
+	})
+}
+{{- end}}
+{{- if .StructInfo.HasConstraints}}
+
+{{template "signature" .Method "RegisterFlagConstraints" "cmd *cobra.Command" "cmd" "" (printf "registers the flag constraints of %s on cmd.\n// Call it after the flags have been added to cmd.Flags()." .StructInfo.Name)}}
+{{- range .StructInfo.Fields}}
+{{- if and .FlagMethod .Required}}
+	_ = cmd.MarkFlagRequired({{$.Flag .FlagName}})
+{{- end}}
+{{- end}}
+{{- range .StructInfo.MutuallyExclusive}}
+	cmd.MarkFlagsMutuallyExclusive({{$.Flags .}})
+{{- end}}
+{{- range .StructInfo.RequiredTogether}}
+	cmd.MarkFlagsRequiredTogether({{$.Flags .}})
+{{- end}}
+{{- range .StructInfo.OneRequired}}
+	cmd.MarkFlagsOneRequired({{$.Flags .}})
+{{- end}}
+}
+{{- end}}
+{{- if .StructInfo.HasCompletions}}
+
+{{template "signature" .Method "RegisterCompletions" "cmd *cobra.Command" "cmd" "error" (printf "registers shell completion for the flag values of %s on cmd.\n// Call it after the flags have been added to cmd.Flags()." .StructInfo.Name)}}
+{{- range .StructInfo.Fields}}
+{{- if and .FlagMethod .Completion}}
+{{- if eq .Completion.Kind "files"}}
+	if err := cmd.MarkFlagFilename({{$.Flag .FlagName}}{{range .Completion.Extensions}}, {{$.Quote .}}{{end}}); err != nil {
+		return err
+	}
+{{- else if eq .Completion.Kind "dirs"}}
+	if err := cmd.MarkFlagDirname({{$.Flag .FlagName}}); err != nil {
+		return err
+	}
+{{- else if eq .Completion.Kind "func"}}
+	if err := cmd.RegisterFlagCompletionFunc({{$.Flag .FlagName}}, {{.Completion.Func}}); err != nil {
+		return err
+	}
+{{- end}}
+{{- end}}
+{{- end}}
+	return nil
+}
+{{- end}}
+{{- if .Groups}}
+
+{{template "signature" .Method "FlagGroups" "flags *pflag.FlagSet" "flags" "[]*pflag.FlagSet" (printf "returns one FlagSet per flag group of %s, named after the group\n// and holding the flags from the given FlagSet in declaration order." .StructInfo.Name)}}
+	groups := []struct {
+		name  string
+		flags []string
+	}{
+{{- range .Groups}}
+		{ {{- $.Quote .Name}}, []string{ {{- $.QuoteList .Flags}}}},
+{{- end}}
+	}
+
+	sets := make([]*pflag.FlagSet, 0, len(groups))
+	for _, group := range groups {
+		set := pflag.NewFlagSet(group.name, pflag.ContinueOnError)
+		set.SortFlags = false
+		for _, name := range group.flags {
+			if flag := flags.Lookup({{if .StructInfo.WithPrefix}}prefix + {{end}}name); flag != nil {
+				set.AddFlag(flag)
+			}
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+{{template "signature" .Method "FlagGroupUsages" "flags *pflag.FlagSet" "flags" "string" (printf "returns the usage of the flags from %s with each group\n// listed under its own heading, e.g. for a cobra usage function." .StructInfo.Name)}}
+	var b strings.Builder
+	for _, set := range o.{{.Call "FlagGroups" "flags"}} {
+		if usages := set.FlagUsages(); usages != "" {
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%s:\n%s", set.Name(), usages)
+		}
+	}
+	return b.String()
+}
+{{- end}}
+{{- end}}
+{{- define "signature"}}
+// {{.Name}} {{.Doc}}
+{{- if .WithPrefix}}
+func (o *{{.Receiver}}) {{.Name}}({{.Params}}){{if .Results}} {{.Results}}{{end}} {
+	{{if .Results}}return {{end}}o.{{.Name}}WithPrefix({{if .Args}}{{.Args}}, {{end}}"")
+}
+
+// {{.Name}}WithPrefix is like {{.Name}}, but prepends prefix to every flag name
+{{- if .Registers}}
+// and registers shorthands only when prefix is empty, as they cannot be prefixed
+{{- end}}
+{{- if eq .Name "ApplyEnv"}}
+// and to every environment variable name, upper-cased with '-' and '.' replaced by '_'
+{{- end}}.
+func (o *{{.Receiver}}) {{.Name}}WithPrefix({{if .Params}}{{.Params}}, {{end}}prefix string){{if .Results}} {{.Results}}{{end}} {
+{{- else}}
+func (o *{{.Receiver}}) {{.Name}}({{.Params}}){{if .Results}} {{.Results}}{{end}} {
+{{- end}}
+{{- end}}
 `
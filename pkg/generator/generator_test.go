@@ -1,9 +1,25 @@
 package generator
 
 import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	goparser "go/parser"
+	"go/token"
+	gotypes "go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/spf13/pflag"
+
+	"github.com/yuvalwz/flags-gen/pkg/flagsrt"
+	"github.com/yuvalwz/flags-gen/pkg/parser"
 	"github.com/yuvalwz/flags-gen/pkg/types"
 )
 
@@ -129,6 +145,52 @@ func TestGenerator_GenerateFlags_NoImports(t *testing.T) {
 	}
 }
 
+func TestGenerator_GenerateFlags_TimeImport(t *testing.T) {
+	generator := New()
+
+	tests := []struct {
+		name     string
+		fields   []types.FieldInfo
+		expected bool
+	}{
+		{
+			name: "string default naming time and duration without default",
+			fields: []types.FieldInfo{
+				{Name: "Format", Type: "string", FlagName: "format", DefaultValue: "time.RFC3339", DefaultValueCode: `"time.RFC3339"`, FlagMethod: "StringVar"},
+				{Name: "Timeout", Type: "time.Duration", FlagName: "timeout", DefaultValueCode: "0", FlagMethod: "DurationVar"},
+			},
+			expected: false,
+		},
+		{
+			name: "duration with default",
+			fields: []types.FieldInfo{
+				{Name: "Timeout", Type: "time.Duration", FlagName: "timeout", DefaultValueCode: "30*time.Second", FlagMethod: "DurationVar"},
+			},
+			expected: true,
+		},
+		{
+			name: "default expression selecting from time",
+			fields: []types.FieldInfo{
+				{Name: "Interval", Type: "int64", FlagName: "interval", DefaultExpr: "int64(time.Minute)", DefaultValueCode: "int64(time.Minute)", FlagMethod: "Int64Var"},
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			structInfo := types.StructInfo{Name: "Config", PackageName: "test", Imports: []string{"time"}, Fields: tt.fields}
+			generated, err := generator.GenerateFlags(&structInfo)
+			if err != nil {
+				t.Fatalf("GenerateFlags failed: %v", err)
+			}
+			if got := strings.Contains(generated, `"time"`); got != tt.expected {
+				t.Errorf("time import = %v, expected %v\n%s", got, tt.expected, generated)
+			}
+		})
+	}
+}
+
 func TestGenerator_formatDefaultValue(t *testing.T) {
 	generator := New()
 
@@ -144,6 +206,7 @@ func TestGenerator_formatDefaultValue(t *testing.T) {
 		{[]string{"web", "api"}, "[]string", `[]string{"web", "api"}`},
 		{[]string{}, "[]string", "[]string{}"},
 		{"30s", "time.Duration", "30s"},
+		{90 * time.Second, "time.Duration", "90*time.Second"},
 	}
 
 	for _, test := range tests {
@@ -176,3 +239,1106 @@ func TestGenerator_getZeroValue(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerator_GenerateExampleConfig(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "TestConfig",
+		PackageName: "main",
+		Description: "TestConfig holds test settings",
+		Fields: []types.FieldInfo{
+			{Name: "Host", Type: "string", JSONTag: "host", Description: "Host is the server hostname", DefaultValue: "localhost", FlagMethod: "StringVar"},
+			{Name: "Port", Type: "int", JSONTag: "port", Description: "Port is the server port", DefaultValue: 8080, FlagMethod: "IntVar"},
+			{Name: "Debug", Type: "bool", Description: "Debug enables debug mode", FlagMethod: "BoolVar"},
+			{Name: "Tags", Type: "[]string", JSONTag: "tags", DefaultValue: []string{"web", "api"}, FlagMethod: "StringSliceVar"},
+			{Name: "Timeout", Type: "time.Duration", JSONTag: "timeout", DefaultValue: "30s", FlagMethod: "DurationVar"},
+			{Name: "Labels", Type: "map[string]string", JSONTag: "labels"},
+		},
+	}
+
+	tests := []struct {
+		format   string
+		expected []string
+	}{
+		{FormatYAML, []string{
+			"# TestConfig holds test settings\n",
+			"# Host is the server hostname\nhost: \"localhost\"\n",
+			"port: 8080\n",
+			"# Debug enables debug mode\nDebug: false\n",
+			"tags:\n  - \"web\"\n  - \"api\"\n",
+			`timeout: "30s"`,
+		}},
+		{FormatTOML, []string{
+			"# TestConfig holds test settings\n",
+			"# Host is the server hostname\nhost = \"localhost\"\n",
+			"port = 8080\n",
+			`tags = ["web", "api"]`,
+			`timeout = "30s"`,
+		}},
+		{FormatJSON, []string{
+			`  "host": "localhost",`,
+			`  "port": 8080,`,
+			`  "Debug": false,`,
+			`  "tags": ["web","api"],`,
+			`  "timeout": "30s"` + "\n}",
+		}},
+	}
+
+	for _, test := range tests {
+		generated, err := generator.GenerateExampleConfig(&structInfo, test.format)
+		if err != nil {
+			t.Fatalf("GenerateExampleConfig(%s) failed: %v", test.format, err)
+		}
+		for _, element := range test.expected {
+			if !strings.Contains(generated, element) {
+				t.Errorf("%s example config missing expected element: %s\n%s", test.format, element, generated)
+			}
+		}
+		if strings.Contains(generated, "labels") {
+			t.Errorf("%s example config should skip fields with unsupported types:\n%s", test.format, generated)
+		}
+		if test.format != FormatTOML {
+			checkExampleConfigLoads(t, test.format, generated)
+		}
+	}
+
+	if _, err := generator.GenerateExampleConfig(&structInfo, "ini"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestGenerator_GenerateExampleConfig_DefaultExpr(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "ProbeConfig",
+		PackageName: "main",
+		Fields: []types.FieldInfo{
+			{Name: "ProbeAddr", Type: "string", JSONTag: "probeAddr", Description: "ProbeAddr is the probe address", DefaultExpr: "DefaultProbeAddr", FlagMethod: "StringVar"},
+			{Name: "Ports", Type: "[]int", JSONTag: "ports", DefaultExpr: "defaultPorts()", FlagMethod: "IntSliceVar"},
+			{Name: "Port", Type: "int", JSONTag: "port", DefaultValue: 8080, FlagMethod: "IntVar"},
+		},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{FormatYAML, "# ProbeAddr is the probe address\n# Defaults to the Go expression DefaultProbeAddr.\n# probeAddr: \"\"\n\n" +
+			"# Defaults to the Go expression defaultPorts().\n# ports: []\n\nport: 8080\n"},
+		{FormatTOML, "# ProbeAddr is the probe address\n# Defaults to the Go expression DefaultProbeAddr.\n# probeAddr = \"\"\n\n" +
+			"# Defaults to the Go expression defaultPorts().\n# ports = []\n\nport = 8080\n"},
+		{FormatJSON, "{\n  \"port\": 8080\n}\n"},
+	}
+
+	// A copied sample must not override expression defaults with zero values
+	for _, test := range tests {
+		generated, err := generator.GenerateExampleConfig(&structInfo, test.format)
+		if err != nil {
+			t.Fatalf("GenerateExampleConfig(%s) failed: %v", test.format, err)
+		}
+		if generated != test.expected {
+			t.Errorf("%s example config:\n%s\nwant:\n%s", test.format, generated, test.expected)
+		}
+	}
+}
+
+func TestGenerator_GenerateExampleConfig_Sensitive(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "ClientConfig",
+		PackageName: "main",
+		Fields: []types.FieldInfo{
+			{Name: "APIKey", Type: "string", JSONTag: "apiKey", Description: "APIKey authenticates the client", DefaultValue: "dev-key", Sensitive: true, FlagMethod: "StringVar"},
+			{Name: "Endpoint", Type: "string", JSONTag: "endpoint", DefaultValue: "https://api.example.com", FlagMethod: "StringVar"},
+		},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{FormatYAML, "# APIKey authenticates the client\n# Sensitive, its default is not published.\n# apiKey: \"\"\n\n" +
+			"endpoint: \"https://api.example.com\"\n"},
+		{FormatTOML, "# APIKey authenticates the client\n# Sensitive, its default is not published.\n# apiKey = \"\"\n\n" +
+			"endpoint = \"https://api.example.com\"\n"},
+		{FormatJSON, "{\n  \"endpoint\": \"https://api.example.com\"\n}\n"},
+	}
+
+	// A copied sample must neither publish nor clear the default of a sensitive field
+	for _, test := range tests {
+		generated, err := generator.GenerateExampleConfig(&structInfo, test.format)
+		if err != nil {
+			t.Fatalf("GenerateExampleConfig(%s) failed: %v", test.format, err)
+		}
+		if generated != test.expected {
+			t.Errorf("%s example config:\n%s\nwant:\n%s", test.format, generated, test.expected)
+		}
+	}
+}
+
+// checkExampleConfigLoads checks that flagsrt.ApplyConfigFile reads the
+// example config generated for the fields of TestGenerator_GenerateExampleConfig
+// with its built-in decoder for format.
+func checkExampleConfigLoads(t *testing.T, format, config string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config."+format)
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("host", "", "")
+	flags.Int("port", 0, "")
+	flags.Bool("debug", true, "")
+	flags.StringSlice("tags", nil, "")
+	flags.Duration("timeout", 0, "")
+	keys := map[string]string{"host": "host", "port": "port", "Debug": "debug", "tags": "tags", "timeout": "timeout"}
+	if err := flagsrt.ApplyConfigFile(flags, path, keys); err != nil {
+		t.Fatalf("ApplyConfigFile(%s) failed: %v", format, err)
+	}
+
+	expected := map[string]string{"host": "localhost", "port": "8080", "debug": "false", "tags": "[web,api]", "timeout": "30s"}
+	for name, value := range expected {
+		if got := flags.Lookup(name).Value.String(); got != value {
+			t.Errorf("%s example config: %s = %s, expected %s", format, name, got, value)
+		}
+	}
+}
+
+func TestGenerator_GenerateFlags_Markers(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "ServerConfig",
+		PackageName: "main",
+		MethodName:  "RegisterFlags",
+		Fields: []types.FieldInfo{
+			{
+				Name:             "Host",
+				Type:             "string",
+				FlagName:         "host",
+				ShortFlag:        "H",
+				EnvVar:           "SERVER_HOST",
+				Description:      "Server hostname",
+				DefaultValueCode: `"localhost"`,
+				FlagMethod:       "StringVar",
+			},
+			{
+				Name:             "Port",
+				Type:             "int",
+				FlagName:         "port",
+				Description:      "Server port",
+				DefaultValueCode: "8080",
+				FlagMethod:       "IntVar",
+			},
+		},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		"import (\n\t\"fmt\"\n\t\"os\"\n\n\t\"github.com/spf13/pflag\"\n)",
+		"func (o *ServerConfig) RegisterFlags(flags *pflag.FlagSet) {",
+		`flags.StringVarP(&o.Host, "host", "H", "localhost", "Server hostname")`,
+		`flags.IntVar(&o.Port, "port", 8080, "Server port")`,
+		"func (o *ServerConfig) ApplyEnv(flags *pflag.FlagSet) error {",
+		`if value, ok := os.LookupEnv("SERVER_HOST"); ok && !flags.Changed("host") {`,
+	}
+
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s", element)
+			t.Errorf("Generated code:\n%s", generated)
+		}
+	}
+
+	if strings.Contains(generated, "AddFlags") {
+		t.Error("Generated code should use the method name from +flags-gen:method")
+	}
+}
+
+func TestGenerator_GenerateFlags_HiddenDeprecatedAliases(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "ServerConfig",
+		PackageName: "main",
+		Fields: []types.FieldInfo{
+			{
+				Name:                "Port",
+				Type:                "int",
+				FlagName:            "listen-port",
+				ShortFlag:           "p",
+				ShorthandDeprecated: "use --listen-port instead",
+				Aliases:             []string{"port"},
+				Description:         "Server port",
+				DefaultValueCode:    "8080",
+				FlagMethod:          "IntVar",
+			},
+			{
+				Name:             "Debug",
+				Type:             "bool",
+				FlagName:         "debug",
+				Hidden:           true,
+				Deprecated:       `debug output is always on, see "--log-level"`,
+				Description:      "Enable debug mode",
+				DefaultValueCode: "false",
+				FlagMethod:       "BoolVar",
+			},
+		},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		`flags.IntVarP(&o.Port, "listen-port", "p", 8080, "Server port")`,
+		`normalize := flags.GetNormalizeFunc()`,
+		`"port": "listen-port",`,
+		`if primary, ok := aliases[string(normalized)]; ok {`,
+		`_ = flags.MarkShorthandDeprecated("listen-port", "use --listen-port instead")`,
+		`_ = flags.MarkHidden("debug")`,
+		`_ = flags.MarkDeprecated("debug", "debug output is always on, see \"--log-level\"")`,
+	}
+
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s", element)
+			t.Errorf("Generated code:\n%s", generated)
+		}
+	}
+
+	// Aliases are normalized names, not flags of their own
+	if strings.Contains(generated, `&o.Port, "port"`) {
+		t.Errorf("Alias should not be registered as a separate flag:\n%s", generated)
+	}
+}
+
+func TestGenerator_GenerateFlags_AliasPrecedence(t *testing.T) {
+	source := `package main
+
+// Config is configured from flags, the environment and a config file.
+// +flags-gen
+// +flags-gen:config-file
+// +flags-gen:provenance
+type Config struct {
+	// Port is the listen port.
+	// +flags-gen:env=PORT
+	// +flags-gen:alias=old-port
+	Port int ` + "`json:\"port\"`" + `
+
+	// Workers is the number of workers.
+	// +flags-gen:env=WORKERS
+	// +flags-gen:alias=threads
+	Workers int ` + "`json:\"workers\"`" + `
+
+	// Level is the log level.
+	// +flags-gen:alias=verbosity
+	Level string ` + "`json:\"level\"`" + `
+}
+`
+	program := `package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"github.com/yuvalwz/flags-gen/pkg/flagsrt"
+)
+
+func main() {
+	dir, err := os.MkdirTemp("", "alias")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(` + "`" + `{"port": 7, "workers": 3, "level": "debug"}` + "`" + `), 0o600); err != nil {
+		panic(err)
+	}
+
+	var config Config
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	config.AddFlags(flags)
+	if err := flags.Parse([]string{"--old-port=5"}); err != nil {
+		panic(err)
+	}
+	if err := config.ApplyEnv(flags); err != nil {
+		panic(err)
+	}
+	if err := config.ApplyConfigFile(flags, path); err != nil {
+		panic(err)
+	}
+	sources := config.Sources(flags)
+	fmt.Println(config.Port, sources["port"], config.Workers, sources["workers"], config.Level, sources["level"])
+
+	// Watch rebuilds the FlagSet with CopyFlags, which must keep the alias value
+	var copied Config
+	dst := pflag.NewFlagSet("copy", pflag.ContinueOnError)
+	copied.AddFlags(dst)
+	if err := flagsrt.CopyFlags(dst, flags, []string{"port", "workers", "level"}); err != nil {
+		panic(err)
+	}
+	fmt.Println(copied.Port, copied.Workers, copied.Level)
+}
+`
+	// The flag given under its alias wins over the environment and the config file
+	output := runGenerated(t, source, program, "PORT=9", "WORKERS=4")
+	if expected := "5 flag 4 env debug config-file\n5 4 \n"; output != expected {
+		t.Errorf("Output = %q, expected %q", output, expected)
+	}
+}
+
+func TestGenerator_GenerateFlags_Constraints(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "TLSConfig",
+		PackageName: "main",
+		Fields: []types.FieldInfo{
+			{Name: "TLSCert", Type: "string", FlagName: "tls-cert", DefaultValueCode: `""`, FlagMethod: "StringVar"},
+			{Name: "TLSKey", Type: "string", FlagName: "tls-key", DefaultValueCode: `""`, FlagMethod: "StringVar"},
+			{Name: "Insecure", Type: "bool", FlagName: "insecure", DefaultValueCode: "false", FlagMethod: "BoolVar"},
+		},
+		MutuallyExclusive: [][]string{{"tls-cert", "insecure"}},
+		RequiredTogether:  [][]string{{"tls-cert", "tls-key"}},
+		OneRequired:       [][]string{{"tls-cert", "insecure"}},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		"import (\n\t\"github.com/spf13/cobra\"\n\t\"github.com/spf13/pflag\"\n)",
+		"func (o *TLSConfig) RegisterFlagConstraints(cmd *cobra.Command) {",
+		`cmd.MarkFlagsMutuallyExclusive("tls-cert", "insecure")`,
+		`cmd.MarkFlagsRequiredTogether("tls-cert", "tls-key")`,
+		`cmd.MarkFlagsOneRequired("tls-cert", "insecure")`,
+	}
+
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s", element)
+			t.Errorf("Generated code:\n%s", generated)
+		}
+	}
+
+	// A required flag alone is a constraint too
+	structInfo = types.StructInfo{
+		Name:        "ClientConfig",
+		PackageName: "main",
+		Fields: []types.FieldInfo{
+			{Name: "Server", Type: "string", FlagName: "server", Required: true, DefaultValueCode: `""`, FlagMethod: "StringVar"},
+		},
+	}
+	generated, err = generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+	if expected := "func (o *ClientConfig) RegisterFlagConstraints(cmd *cobra.Command) {\n\t_ = cmd.MarkFlagRequired(\"server\")\n}"; !strings.Contains(generated, expected) {
+		t.Errorf("Generated code missing expected element: %s\n%s", expected, generated)
+	}
+}
+
+func TestGenerator_GenerateFlags_Groups(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "ServerConfig",
+		PackageName: "main",
+		Fields: []types.FieldInfo{
+			{Name: "Host", Type: "string", FlagName: "host", DefaultValueCode: `""`, FlagMethod: "StringVar"},
+			{Name: "MetricsAddr", Type: "string", FlagName: "metrics-addr", Group: "Metrics", DefaultValueCode: `""`, FlagMethod: "StringVar"},
+			{Name: "Port", Type: "int", FlagName: "port", DefaultValueCode: "0", FlagMethod: "IntVar"},
+			{Name: "MetricsPath", Type: "string", FlagName: "metrics-path", Group: "Metrics", DefaultValueCode: `""`, FlagMethod: "StringVar"},
+		},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		"func (o *ServerConfig) FlagGroups(flags *pflag.FlagSet) []*pflag.FlagSet {",
+		`{"Flags", []string{"host", "port"}},`,
+		`{"Metrics", []string{"metrics-addr", "metrics-path"}},`,
+		"func (o *ServerConfig) FlagGroupUsages(flags *pflag.FlagSet) string {",
+	}
+
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s", element)
+			t.Errorf("Generated code:\n%s", generated)
+		}
+	}
+
+	// Without group markers no grouped usage is generated
+	for i := range structInfo.Fields {
+		structInfo.Fields[i].Group = ""
+	}
+	generated, err = generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+	if strings.Contains(generated, "FlagGroups") {
+		t.Error("Generated code should not contain FlagGroups when no field has a group")
+	}
+}
+
+func TestGenerator_GenerateFlags_Completions(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "Config",
+		PackageName: "main",
+		Fields: []types.FieldInfo{
+			{
+				Name: "ConfigFile", Type: "string", FlagName: "config-file", DefaultValueCode: `""`, FlagMethod: "StringVar",
+				Completion: &types.Completion{Kind: types.CompleteFiles, Extensions: []string{"yaml", "yml"}},
+			},
+			{
+				Name: "DataDir", Type: "string", FlagName: "data-dir", DefaultValueCode: `""`, FlagMethod: "StringVar",
+				Completion: &types.Completion{Kind: types.CompleteDirs},
+			},
+			{
+				Name: "Namespace", Type: "string", FlagName: "namespace", DefaultValueCode: `""`, FlagMethod: "StringVar",
+				Completion: &types.Completion{Kind: types.CompleteFunc, Func: "CompleteNamespaces"},
+			},
+		},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		`"github.com/spf13/cobra"`,
+		"func (o *Config) RegisterCompletions(cmd *cobra.Command) error {",
+		`if err := cmd.MarkFlagFilename("config-file", "yaml", "yml"); err != nil {`,
+		`if err := cmd.MarkFlagDirname("data-dir"); err != nil {`,
+		`if err := cmd.RegisterFlagCompletionFunc("namespace", CompleteNamespaces); err != nil {`,
+	}
+
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s", element)
+			t.Errorf("Generated code:\n%s", generated)
+		}
+	}
+}
+
+func TestGenerator_GenerateFlags_WithPrefix(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "ClientConfig",
+		PackageName: "main",
+		MethodName:  "RegisterFlags",
+		WithPrefix:  true,
+		Fields: []types.FieldInfo{
+			{Name: "Server", Type: "string", FlagName: "server", EnvVar: "SERVER", Aliases: []string{"host"}, DefaultValueCode: `""`, FlagMethod: "StringVar"},
+			{Name: "Token", Type: "string", FlagName: "token", Group: "Auth", DefaultValueCode: `""`, FlagMethod: "StringVar"},
+			{Name: "Insecure", Type: "bool", FlagName: "insecure", DefaultValueCode: "false", FlagMethod: "BoolVar"},
+		},
+		MutuallyExclusive: [][]string{{"token", "insecure"}},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		"func (o *ClientConfig) RegisterFlags(flags *pflag.FlagSet) {\n\to.RegisterFlagsWithPrefix(flags, \"\")\n}",
+		"// RegisterFlagsWithPrefix is like RegisterFlags, but prepends prefix to every flag name\n" +
+			"// and registers shorthands only when prefix is empty, as they cannot be prefixed.\n",
+		"func (o *ClientConfig) RegisterFlagsWithPrefix(flags *pflag.FlagSet, prefix string) {",
+		`flags.StringVar(&o.Server, prefix+"server", "", "")`,
+		`prefix + "host": prefix + "server",`,
+		"func (o *ClientConfig) ApplyEnv(flags *pflag.FlagSet) error {\n\treturn o.ApplyEnvWithPrefix(flags, \"\")\n}",
+		`envPrefix := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(prefix))`,
+		`if value, ok := os.LookupEnv(envPrefix + "SERVER"); ok && !flags.Changed(prefix+"server") {`,
+		"func (o *ClientConfig) RegisterFlagConstraintsWithPrefix(cmd *cobra.Command, prefix string) {",
+		`cmd.MarkFlagsMutuallyExclusive(prefix+"token", prefix+"insecure")`,
+		"if flag := flags.Lookup(prefix + name); flag != nil {",
+		"for _, set := range o.FlagGroupsWithPrefix(flags, prefix) {",
+	}
+
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s", element)
+			t.Errorf("Generated code:\n%s", generated)
+		}
+	}
+
+	// Without the marker no prefixed variants are generated
+	structInfo.WithPrefix = false
+	generated, err = generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+	if strings.Contains(generated, "WithPrefix") || strings.Contains(generated, "prefix+") {
+		t.Errorf("Generated code should not contain prefixed variants without WithPrefix:\n%s", generated)
+	}
+}
+
+// runGenerated generates the flags code for the annotated structs of source, a
+// main package, and runs it with program, the source of its main function,
+// in a temporary package inside the module. It returns the program output.
+func runGenerated(t *testing.T, source, program string, env ...string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping go run in short mode")
+	}
+
+	structs, err := parser.New().ParseSource("config.go", []byte(source))
+	if err != nil {
+		t.Fatalf("ParseSource failed: %v", err)
+	}
+	generated, err := New().GenerateFile(structs)
+	if err != nil {
+		t.Fatalf("GenerateFile failed: %v", err)
+	}
+
+	// Directories starting with _ are left out of ./... patterns
+	dir, err := os.MkdirTemp(".", "_run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, content := range map[string]string{"config.go": source, "config_flags.go": generated, "main.go": program} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Running generated code failed: %v\n%s\ngenerated:\n%s", err, output, generated)
+	}
+	return string(output)
+}
+
+func TestGenerator_GenerateFlags_WithPrefixShorthand(t *testing.T) {
+	source := `package main
+
+// ClientConfig is registered once per endpoint.
+// +flags-gen
+// +flags-gen:with-prefix
+type ClientConfig struct {
+	// Insecure skips TLS verification.
+	// +flags-gen:short=k
+	Insecure bool
+
+	// Server is the server address.
+	Server string
+}
+`
+	program := `package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+func main() {
+	var plain, source, target ClientConfig
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	plain.AddFlags(flags)
+	source.AddFlagsWithPrefix(flags, "source-")
+	target.AddFlagsWithPrefix(flags, "target-")
+	if err := flags.Parse([]string{"-k", "--source-insecure", "--target-server=b"}); err != nil {
+		panic(err)
+	}
+	fmt.Println(plain.Insecure, source.Insecure, target.Insecure, target.Server, flags.Lookup("target-insecure").Shorthand == "")
+}
+`
+	// Registering the struct twice used to panic on the redefined shorthand
+	if output := runGenerated(t, source, program); output != "true true false b true\n" {
+		t.Errorf("Output = %q", output)
+	}
+}
+
+func TestGenerator_GenerateFlags_Provenance(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "Config",
+		PackageName: "main",
+		Provenance:  true,
+		ConfigFile:  true,
+		Fields: []types.FieldInfo{
+			{Name: "MetricsAddr", Type: "string", JSONTag: "metricsAddr", FlagName: "metrics-addr", EnvVar: "METRICS_ADDR", DefaultValueCode: `""`, FlagMethod: "StringVar"},
+			{Name: "Workers", Type: "int", FlagName: "workers", DefaultValueCode: "0", FlagMethod: "IntVar"},
+		},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		`"io"`,
+		`"github.com/yuvalwz/flags-gen/pkg/flagsrt"`,
+		`if err := flagsrt.Set(flags, "metrics-addr", value, flagsrt.SourceEnv); err != nil {`,
+		"func (o *Config) ApplyConfigFile(flags *pflag.FlagSet, path string) error {",
+		`"metricsAddr": "metrics-addr",`,
+		`"Workers":     "workers",`,
+		"func (o *Config) Sources(flags *pflag.FlagSet) map[string]flagsrt.Source {",
+		`return flagsrt.Sources(flags, []string{"metrics-addr", "workers"})`,
+		"func (o *Config) PrintEffectiveConfig(w io.Writer, flags *pflag.FlagSet) error {",
+	}
+
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s", element)
+			t.Errorf("Generated code:\n%s", generated)
+		}
+	}
+
+	// Without the markers the generated code does not depend on flagsrt
+	structInfo.Provenance = false
+	structInfo.ConfigFile = false
+	generated, err = generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+	if strings.Contains(generated, "flagsrt") {
+		t.Errorf("Generated code should not use flagsrt without runtime markers:\n%s", generated)
+	}
+}
+
+func TestGenerator_GenerateFlags_Sensitive(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "Config",
+		PackageName: "main",
+		Provenance:  true,
+		Fields: []types.FieldInfo{
+			{Name: "Host", Type: "string", JSONTag: "host", FlagName: "host", DefaultValue: "localhost", DefaultValueCode: `"localhost"`, FlagMethod: "StringVar"},
+			{
+				Name: "APIKey", Type: "string", JSONTag: "apiKey", FlagName: "api-key", EnvVar: "API_KEY", Sensitive: true,
+				DefaultValue: "dev-key", DefaultValueCode: `"dev-key"`, FlagMethod: "StringVar",
+			},
+		},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		`flags.StringVar(&o.APIKey, "api-key", "", "")`,
+		`o.APIKey = "dev-key"`,
+		`return fmt.Errorf("invalid value for environment variable %s: %w", "API_KEY", err)`,
+		`return flagsrt.PrintEffectiveConfig(w, flags, []string{"host", "api-key"}, []string{"api-key"})`,
+		"func (o Config) String() string {",
+		`return fmt.Sprintf("Config{Host:%v APIKey:[REDACTED]}", o.Host)`,
+		"func (o Config) MarshalLogJSON() ([]byte, error) {",
+		"APIKey string `json:\"apiKey\"`",
+		`APIKey: "[REDACTED]",`,
+	}
+
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s", element)
+			t.Errorf("Generated code:\n%s", generated)
+		}
+	}
+
+	config, err := generator.GenerateExampleConfig(&structInfo, FormatYAML)
+	if err != nil {
+		t.Fatalf("GenerateExampleConfig failed: %v", err)
+	}
+	if strings.Contains(config, "dev-key") {
+		t.Errorf("Example config should not contain the sensitive default:\n%s", config)
+	}
+}
+
+func TestGenerator_GenerateFlags_FromFile(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "Config",
+		PackageName: "main",
+		Fields: []types.FieldInfo{
+			{Name: "APIKey", Type: "string", FlagName: "api-key", EnvVar: "API_KEY", FromFile: true, DefaultValueCode: `""`, FlagMethod: "StringVar"},
+			{Name: "Workers", Type: "int", FlagName: "workers", DefaultValueCode: "0", FlagMethod: "IntVar"},
+		},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		`flags.String("api-key-file", "", "Read --api-key from the file at this path")`,
+		`if value, ok := os.LookupEnv("API_KEY"); ok && !flags.Changed("api-key") && !flags.Changed("api-key-file") {`,
+		`if value, ok := os.LookupEnv("API_KEY_FILE"); ok && !flags.Changed("api-key") && !flags.Changed("api-key-file") {`,
+		`if err := flags.Set("api-key-file", value); err != nil {`,
+		"func (o *Config) ReadFileFlags(flags *pflag.FlagSet) error {",
+		`return fmt.Errorf("--%s and --%s cannot both be set", "api-key", "api-key-file")`,
+		`if err := flags.Set("api-key", strings.TrimSpace(string(data))); err != nil {`,
+	}
+
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s", element)
+			t.Errorf("Generated code:\n%s", generated)
+		}
+	}
+	if strings.Contains(generated, "workers-file") {
+		t.Errorf("Generated code should only add -file flags for from-file fields:\n%s", generated)
+	}
+}
+
+func TestGenerator_GenerateFlags_Watch(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "RuntimeConfig",
+		PackageName: "main",
+		Watch:       true,
+		ConfigFile:  true,
+		Fields: []types.FieldInfo{
+			{Name: "Level", Type: "string", JSONTag: "level", FlagName: "level", DefaultValueCode: `""`, FlagMethod: "StringVar"},
+			{Name: "Workers", Type: "int", JSONTag: "workers", FlagName: "workers", DefaultValueCode: "0", FlagMethod: "IntVar"},
+		},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		`"context"`,
+		"func (o *RuntimeConfig) Watch(ctx context.Context, flags *pflag.FlagSet, path string, onChange func(old, new *RuntimeConfig)) error {",
+		"return flagsrt.WatchFile(ctx, path, func() error {",
+		"next.AddFlags(set)",
+		`if err := flagsrt.CopyFlags(set, flags, []string{"level", "workers"}); err != nil {`,
+		"if err := next.ApplyConfigFile(set, path); err != nil {",
+		"if validator, ok := interface{}(next).(interface{ Validate() error }); ok {",
+		"onChange(old, next)",
+	}
+
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s", element)
+			t.Errorf("Generated code:\n%s", generated)
+		}
+	}
+}
+
+func TestGenerator_GenerateFlags_WatchReload(t *testing.T) {
+	source := `package main
+
+// RuntimeConfig is reloaded from its config file.
+// +flags-gen
+// +flags-gen:watch
+type RuntimeConfig struct {
+	// Level is the log level.
+	// +kubebuilder:validation:Enum=debug;info
+	Level string ` + "`json:\"level\" default:\"info\"`" + `
+
+	// Workers is the number of workers.
+	Workers int ` + "`json:\"workers\"`" + `
+
+	// Name is set in code.
+	// +flags-gen:skip
+	Name string
+}
+`
+	program := `package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/yuvalwz/flags-gen/pkg/flagsrt"
+)
+
+func main() {
+	dir, err := os.MkdirTemp("", "watch")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			panic(err)
+		}
+	}
+	write(` + "`" + `{"workers": 3, "level": "info"}` + "`" + `)
+
+	var config RuntimeConfig
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	config.AddFlags(flags)
+	if err := flags.Parse([]string{"--workers=5"}); err != nil {
+		panic(err)
+	}
+	if err := config.ApplyConfigFile(flags, path); err != nil {
+		panic(err)
+	}
+	config.Name = "set-in-code"
+
+	errs := make(chan error, 1)
+	changes := make(chan [2]*RuntimeConfig, 1)
+	flagsrt.WatchInterval = 10 * time.Millisecond
+	flagsrt.ReloadErrorHandler = func(_ string, err error) { errs <- err }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = config.Watch(ctx, flags, path, func(old, new *RuntimeConfig) { changes <- [2]*RuntimeConfig{old, new} })
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// A config failing validation is rejected
+	write(` + "`" + `{"workers": 3, "level": "trace"}` + "`" + `)
+	select {
+	case err := <-errs:
+		fmt.Println("rejected:", err != nil)
+	case <-changes:
+		fmt.Println("invalid config accepted")
+	case <-time.After(10 * time.Second):
+		fmt.Println("timeout")
+	}
+
+	write(` + "`" + `{"workers": 3, "level": "debug"}` + "`" + `)
+	select {
+	case change := <-changes:
+		fmt.Println(change[0].Level, change[1].Level, change[1].Workers, change[1].Name)
+	case err := <-errs:
+		fmt.Println("reload failed:", err)
+	case <-time.After(10 * time.Second):
+		fmt.Println("timeout")
+	}
+}
+`
+	// The flag beats the file, the invalid reload is dropped and Name survives
+	if output := runGenerated(t, source, program); output != "rejected: true\ninfo debug 5 set-in-code\n" {
+		t.Errorf("Output = %q", output)
+	}
+}
+
+func TestGenerator_GenerateFlags_DefaultExpr(t *testing.T) {
+	generator := New()
+
+	structInfo := types.StructInfo{
+		Name:        "TestConfig",
+		PackageName: "main",
+		Imports:     []string{"runtime", "flag github.com/spf13/pflag", "corev1 k8s.io/api/core/v1"},
+		Fields: []types.FieldInfo{
+			{
+				Name: "Workers", Type: "int", FlagName: "workers", FlagMethod: "IntVar",
+				DefaultExpr: "runtime.NumCPU()", DefaultValueCode: "runtime.NumCPU()",
+			},
+			{
+				Name: "Token", Type: "string", FlagName: "token", FlagMethod: "StringVar", Sensitive: true,
+				DefaultExpr: "DefaultToken", DefaultValueCode: "DefaultToken",
+			},
+		},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		"import (\n\t\"encoding/json\"\n\t\"fmt\"\n\t\"runtime\"\n\n",
+		`corev1 "k8s.io/api/core/v1"`,
+		`flag "github.com/spf13/pflag"`,
+		`flags.IntVar(&o.Workers, "workers", runtime.NumCPU(), "")`,
+		`flags.StringVar(&o.Token, "token", "", "")`,
+		`o.Token = DefaultToken`,
+	}
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s\nGenerated code:\n%s", element, generated)
+		}
+	}
+}
+
+func TestGenerator_GenerateFlags_Validation(t *testing.T) {
+	generator := New()
+
+	minLength := 8
+	structInfo := types.StructInfo{
+		Name:        "ServerConfig",
+		PackageName: "main",
+		Watch:       true,
+		ConfigFile:  true,
+		Fields: []types.FieldInfo{
+			{
+				Name: "Port", Type: "int", FlagName: "port", DefaultValueCode: "8080", FlagMethod: "IntVar",
+				Validation: &types.Validation{Minimum: "1", Maximum: "65535", ExclusiveMaximum: true},
+			},
+			{
+				Name: "Name", Type: "string", FlagName: "name", DefaultValueCode: `""`, FlagMethod: "StringVar",
+				Validation: &types.Validation{Pattern: "^[a-z]+%$"},
+			},
+			{
+				Name: "Level", Type: "string", FlagName: "level", DefaultValueCode: `""`, FlagMethod: "StringVar",
+				Validation: &types.Validation{Enum: []string{`"debug"`, `"info"`}},
+			},
+			{
+				Name: "Token", Type: "string", FlagName: "token", DefaultValueCode: `""`, FlagMethod: "StringVar", Sensitive: true,
+				Validation: &types.Validation{MinLength: &minLength},
+			},
+		},
+	}
+
+	generated, err := generator.GenerateFlags(&structInfo)
+	if err != nil {
+		t.Fatalf("GenerateFlags failed: %v", err)
+	}
+
+	expectedElements := []string{
+		`"regexp"`,
+		`"unicode/utf8"`,
+		"var serverConfigNamePattern = regexp.MustCompile(\"^[a-z]+%$\")",
+		"func (o *ServerConfig) ValidateFlags() error {",
+		"if o.Port < 1 {",
+		`errs = append(errs, fmt.Errorf("%s, got %v", "--port must be at least 1", o.Port))`,
+		"if o.Port >= 65535 {",
+		`"--port must be less than 65535"`,
+		"if !serverConfigNamePattern.MatchString(o.Name) {",
+		`"--name must match the pattern ^[a-z]+%$"`,
+		`if o.Level != "debug" && o.Level != "info" {`,
+		`errs = append(errs, errors.New("--token must be at least 8 characters long"))`,
+		"return errors.Join(errs...)",
+		"if err := next.ValidateFlags(); err != nil {",
+	}
+	for _, element := range expectedElements {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s\nGenerated code:\n%s", element, generated)
+		}
+	}
+}
+
+func TestGenerator_GenerateFile(t *testing.T) {
+	generator := New()
+
+	structs := []types.StructInfo{
+		{
+			Name: "ServerConfig", PackageName: "config", Imports: []string{"time"},
+			Fields: []types.FieldInfo{{Name: "Timeout", Type: "time.Duration", FlagName: "timeout", DefaultValueCode: "30*time.Second", FlagMethod: "DurationVar"}},
+		},
+		{
+			Name: "ClientConfig", PackageName: "config",
+			Fields: []types.FieldInfo{{Name: "Token", Type: "string", FlagName: "token", EnvVar: "TOKEN", DefaultValueCode: `""`, FlagMethod: "StringVar"}},
+		},
+	}
+
+	generated, err := generator.GenerateFile(structs)
+	if err != nil {
+		t.Fatalf("GenerateFile failed: %v", err)
+	}
+	for _, element := range []string{"package config", "import (", `"time"`, `"os"`, `"github.com/spf13/pflag"`} {
+		if strings.Count(generated, element) != 1 {
+			t.Errorf("Expected %s once, got %d\nGenerated code:\n%s", element, strings.Count(generated, element), generated)
+		}
+	}
+	for _, element := range []string{"func (o *ServerConfig) AddFlags(flags *pflag.FlagSet) {", "func (o *ClientConfig) ApplyEnv(flags *pflag.FlagSet) error {"} {
+		if !strings.Contains(generated, element) {
+			t.Errorf("Generated code missing expected element: %s\nGenerated code:\n%s", element, generated)
+		}
+	}
+
+	structs[1].PackageName = "client"
+	if _, err := generator.GenerateFile(structs); err == nil || !strings.Contains(err.Error(), "struct ClientConfig is in package client, not config") {
+		t.Errorf("Expected package mismatch error, got %v", err)
+	}
+}
+
+// fuzzTypeCheck holds the importer shared by the iterations of
+// FuzzGenerateFlags, so pflag is loaded from source only once.
+var fuzzTypeCheck struct {
+	sync.Mutex
+	fileSet  *token.FileSet
+	importer gotypes.Importer
+}
+
+// FuzzGenerateFlags generates flags for a struct with an arbitrary doc
+// comment, flag name and defaults, and checks that the output compiles.
+func FuzzGenerateFlags(f *testing.F) {
+	f.Add("Server hostname", "host", "localhost", "a,b")
+	f.Add(`Say "hello" to C:\Users`, `we"ird\name`, `C:\path\"quoted"`, `"a,b",c`)
+	f.Add("First line\nsecond line with `backquotes`", "line\nbreak", "tab\there", "`x`")
+	f.Add("Percent %s %d %%v and {{.Name}}", "{{.Flag}}", "%v", "%%")
+	f.Add("+flags-gen:deprecated=use \"--new\" \\ instead\n+flags-gen:group=Net\"work", "host", "", "")
+	f.Add("+flags-gen:short=h\n+flags-gen:shorthand-deprecated=\"-h\" is gone\n+flags-gen:sensitive", "x", "s3cr\"et", "")
+	f.Add("Unicode ☃ \u00e9 \x7f control", "☃", "\u2028", "é,\x00")
+	f.Add("*/ /* comment terminators", "*/", "/*", "//")
+	f.Add("Time layout", "format", "time.RFC3339", "time.Second")
+
+	f.Fuzz(func(t *testing.T, comment, flagName, stringDefault, sliceDefault string) {
+		var b strings.Builder
+		b.WriteString("package main\n\nimport \"time\"\n\n// Config is fuzzed.\n// +flags-gen\ntype Config struct {\n")
+		for _, line := range strings.Split(comment, "\n") {
+			fmt.Fprintf(&b, "\t// %s\n", line)
+		}
+		fmt.Fprintf(&b, "\tName string %s\n", strconv.Quote("flag:"+strconv.Quote(flagName)+" default:"+strconv.Quote(stringDefault)))
+		fmt.Fprintf(&b, "\tTags []string %s\n", strconv.Quote("default:"+strconv.Quote(sliceDefault)))
+		b.WriteString("\tTimeout time.Duration\n}\n")
+		source := b.String()
+
+		structs, err := parser.New(parser.WithStrict(false)).ParseSource("config.go", []byte(source))
+		if err != nil || len(structs) != 1 {
+			// Inputs the parser rejects, e.g. invalid markers, never reach the generator
+			t.Skip()
+		}
+
+		generated, err := New().GenerateFlags(&structs[0])
+		if err != nil {
+			t.Fatalf("GenerateFlags failed: %v\nsource:\n%s", err, source)
+		}
+
+		fuzzTypeCheck.Lock()
+		defer fuzzTypeCheck.Unlock()
+		if fuzzTypeCheck.importer == nil {
+			fuzzTypeCheck.fileSet = token.NewFileSet()
+			fuzzTypeCheck.importer = importer.ForCompiler(fuzzTypeCheck.fileSet, "source", nil)
+		}
+		var files []*ast.File
+		for name, content := range map[string]string{"config.go": source, "config_flags.go": generated} {
+			file, err := goparser.ParseFile(fuzzTypeCheck.fileSet, name, content, 0)
+			if err != nil {
+				t.Fatalf("Parsing %s failed: %v\ngenerated:\n%s", name, err, generated)
+			}
+			files = append(files, file)
+		}
+		conf := gotypes.Config{Importer: fuzzTypeCheck.importer}
+		if _, err := conf.Check("main", fuzzTypeCheck.fileSet, files, nil); err != nil {
+			t.Fatalf("Generated code does not type-check: %v\nsource:\n%s\ngenerated:\n%s", err, source, generated)
+		}
+	})
+}
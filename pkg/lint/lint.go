@@ -0,0 +1,118 @@
+// Package lint checks structs annotated with +flags-gen for problems that do
+// not stop generation but produce surprising flags, such as fields silently
+// left without a flag or defaults that do not parse for the field type.
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/yuvalwz/flags-gen/pkg/parser"
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+// Rule names reported in Issue.Rule.
+const (
+	RuleUnsupportedType    = "unsupported-type"
+	RuleMissingDescription = "missing-description"
+	RuleInvalidDefault     = "invalid-default"
+	RuleDescriptionPrefix  = "description-prefix"
+	RuleTagMismatch        = "tag-mismatch"
+)
+
+// Issue is a problem found in an annotated struct field.
+type Issue struct {
+	Rule    string         `json:"rule"`
+	Struct  string         `json:"struct"`
+	Field   string         `json:"field"`
+	Pos     types.Position `json:"position"`
+	Message string         `json:"message"`
+}
+
+// String returns the issue as "file:line:col: message (rule)".
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Pos, i.Message, i.Rule)
+}
+
+// Lint returns the issues found in the fields of structs, in field order.
+func Lint(structs []types.StructInfo) []Issue {
+	var issues []Issue
+	for i := range structs {
+		issues = append(issues, lintStruct(&structs[i])...)
+	}
+	return issues
+}
+
+// lintStruct returns the issues found in the fields of one struct.
+func lintStruct(structInfo *types.StructInfo) []Issue {
+	var issues []Issue
+
+	// yaml tags are only expected once the struct uses them
+	usesYAML := false
+	for i := range structInfo.Fields {
+		if _, ok := reflect.StructTag(structInfo.Fields[i].Tag).Lookup("yaml"); ok {
+			usesYAML = true
+		}
+	}
+
+	for i := range structInfo.Fields {
+		field := &structInfo.Fields[i]
+		report := func(rule, format string, args ...interface{}) {
+			issues = append(issues, Issue{
+				Rule:    rule,
+				Struct:  structInfo.Name,
+				Field:   field.Name,
+				Pos:     field.Pos,
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+
+		if field.FlagMethod == "" {
+			report(RuleUnsupportedType, "field %s has unsupported type %s and gets no flag; change its type or add +flags-gen:skip", field.Name, field.Type)
+			continue
+		}
+
+		// A usage tag replaces the doc comment in --help
+		_, hasUsage := reflect.StructTag(field.Tag).Lookup("usage")
+		switch {
+		case field.Description == "":
+			report(RuleMissingDescription, "field %s has no doc comment to describe --%s", field.Name, field.FlagName)
+		case !hasUsage && !startsWithWord(field.Description, field.Name):
+			report(RuleDescriptionPrefix, "doc comment of field %s should start with %q", field.Name, field.Name)
+		}
+
+		if value, ok := reflect.StructTag(field.Tag).Lookup("default"); ok && field.DefaultExpr == "" {
+			if _, err := parser.ParseDefault(value, field.Type); err != nil {
+				report(RuleInvalidDefault, "invalid default for field %s: %v", field.Name, err)
+			}
+		}
+
+		jsonName, hasJSON := tagName(field.Tag, "json")
+		yamlName, hasYAML := tagName(field.Tag, "yaml")
+		switch {
+		case hasJSON && hasYAML && jsonName != yamlName:
+			report(RuleTagMismatch, "field %s has json name %q but yaml name %q", field.Name, jsonName, yamlName)
+		case usesYAML && hasJSON != hasYAML:
+			report(RuleTagMismatch, "field %s has only one of the json and yaml tags used by %s", field.Name, structInfo.Name)
+		}
+	}
+
+	return issues
+}
+
+// startsWithWord reports whether text starts with word followed by a word boundary.
+func startsWithWord(text, word string) bool {
+	rest, ok := strings.CutPrefix(text, word)
+	return ok && (rest == "" || strings.IndexAny(rest[:1], " \t.,:;'") == 0)
+}
+
+// tagName returns the name part of a json or yaml style struct tag.
+func tagName(tag, key string) (string, bool) {
+	value, ok := reflect.StructTag(tag).Lookup(key)
+	if !ok {
+		return "", false
+	}
+	name, _, _ := strings.Cut(value, ",")
+	return name, true
+}
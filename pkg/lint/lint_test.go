@@ -0,0 +1,70 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+func TestLint(t *testing.T) {
+	structs := []types.StructInfo{{
+		Name: "Config",
+		Fields: []types.FieldInfo{
+			{
+				Name: "Port", Type: "int", FlagName: "port", FlagMethod: "IntVar",
+				Description: "Port is the port.", Tag: `json:"port" yaml:"port" default:"80x"`,
+			},
+			{
+				Name: "Timeout", Type: "time.Duration", FlagName: "timeout", FlagMethod: "DurationVar",
+				Description: "the timeout", Tag: `json:"timeout" yaml:"timeOut" default:"5 minutes"`,
+			},
+			{Name: "Labels", Type: "map[string]string", FlagName: "labels", Tag: `json:"labels"`},
+			{Name: "Name", Type: "string", FlagName: "name", FlagMethod: "StringVar", Tag: `json:"name"`},
+			{
+				Name: "Size", Type: "uint32", FlagName: "size", FlagMethod: "Uint32Var",
+				Description: "Size limits the size.", Tag: `json:"size" yaml:"size" default:"5000000000"`,
+			},
+			{
+				Name: "Workers", Type: "int", FlagName: "workers", FlagMethod: "IntVar", DefaultExpr: "runtime.NumCPU()",
+				Description: "Workers is the worker count.", Tag: `json:"workers" yaml:"workers" default:"=runtime.NumCPU()"`,
+			},
+			{
+				Name: "Portable", Type: "bool", FlagName: "portable", FlagMethod: "BoolVar",
+				Description: "Ports are ignored.", Tag: `json:"portable" yaml:"portable" default:"true"`,
+			},
+		},
+	}}
+
+	var rules []string
+	for _, issue := range Lint(structs) {
+		rules = append(rules, issue.Field+":"+issue.Rule)
+	}
+
+	expected := []string{
+		"Port:" + RuleInvalidDefault,
+		"Timeout:" + RuleDescriptionPrefix,
+		"Timeout:" + RuleInvalidDefault,
+		"Timeout:" + RuleTagMismatch,
+		"Labels:" + RuleUnsupportedType,
+		"Name:" + RuleMissingDescription,
+		"Name:" + RuleTagMismatch,
+		"Size:" + RuleInvalidDefault,
+		"Portable:" + RuleDescriptionPrefix,
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("Lint issues:\n got %v\nwant %v", rules, expected)
+	}
+}
+
+func TestIssue_String(t *testing.T) {
+	issue := Issue{
+		Rule:    RuleMissingDescription,
+		Pos:     types.Position{Filename: "config.go", Line: 12, Column: 2},
+		Message: "field Name has no doc comment to describe --name",
+	}
+	expected := "config.go:12:2: field Name has no doc comment to describe --name (missing-description)"
+	if issue.String() != expected {
+		t.Errorf("String() = %q, want %q", issue.String(), expected)
+	}
+}
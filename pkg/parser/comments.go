@@ -0,0 +1,151 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc/comment"
+	"strings"
+	"unicode"
+)
+
+// DescriptionMode selects the part of a doc comment used as the usage of a
+// flag in --help.
+type DescriptionMode int
+
+const (
+	// FirstSentence uses the first sentence of the doc comment, which godoc
+	// also shows as the summary of a declaration.
+	FirstSentence DescriptionMode = iota
+	// FullText uses the whole doc comment, paragraphs and lists included.
+	FullText
+)
+
+// descriptionModes maps the names accepted by LookupDescriptionMode to modes.
+var descriptionModes = map[string]DescriptionMode{
+	"first-sentence": FirstSentence,
+	"full":           FullText,
+}
+
+// LookupDescriptionMode returns the description mode with the given name,
+// "first-sentence" or "full".
+func LookupDescriptionMode(name string) (DescriptionMode, error) {
+	mode, ok := descriptionModes[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown description mode %q (supported: first-sentence, full)", name)
+	}
+	return mode, nil
+}
+
+// WithDescriptionMode sets the part of doc comments used as flag usages. The
+// default is FirstSentence. A usage tag always wins over the doc comment.
+func WithDescriptionMode(mode DescriptionMode) Option {
+	return func(p *Parser) {
+		p.descriptionMode = mode
+	}
+}
+
+// parseDoc returns the text of a doc comment formatted as godoc does: one line
+// per paragraph, lists and code blocks indented, and doc links such as
+// [time.Duration] without their brackets. Marker lines are left out. The first
+// inline comment is used when there is no doc comment.
+func (p *Parser) parseDoc(comment, doc *ast.CommentGroup) string {
+	text := commentText(doc)
+	if text == "" && comment != nil && len(comment.List) > 0 {
+		text = commentText(&ast.CommentGroup{List: comment.List[:1]})
+	}
+	if text == "" {
+		return ""
+	}
+	return formatDoc(text)
+}
+
+// description returns the flag usage for a field or struct with the doc text
+// returned by parseDoc, according to the description mode.
+func (p *Parser) description(doc string) string {
+	if p.descriptionMode == FullText {
+		return doc
+	}
+	return firstSentence(doc)
+}
+
+// commentText returns the lines of a comment group without comment markers
+// and +marker lines, keeping the blank lines that separate paragraphs and the
+// indentation of lists and code blocks.
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+
+	var lines []string
+	for _, c := range group.List {
+		var text []string
+		if strings.HasPrefix(c.Text, "/*") {
+			text = unindent(strings.Split(strings.TrimSuffix(strings.TrimPrefix(c.Text, "/*"), "*/"), "\n"))
+		} else {
+			text = []string{strings.TrimPrefix(c.Text, "//")}
+		}
+		for _, line := range text {
+			line = strings.TrimRightFunc(strings.TrimPrefix(line, " "), unicode.IsSpace)
+			// Skip annotations like +optional
+			if strings.HasPrefix(strings.TrimSpace(line), "+") {
+				continue
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// unindent removes the indentation common to the lines of a block comment
+// after the first, which is usually aligned with the text after "/*".
+func unindent(lines []string) []string {
+	indent, found := "", false
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			indent, found = lineIndent, true
+		}
+		for !strings.HasPrefix(lineIndent, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(lines[i], indent)
+	}
+	return lines
+}
+
+// formatDoc parses text as a doc comment and prints it back as plain text.
+// Every doc link is resolved, as the parser does not load the packages and
+// declarations they name.
+func formatDoc(text string) string {
+	parser := comment.Parser{
+		LookupPackage: func(name string) (string, bool) { return name, true },
+		LookupSym:     func(recv, name string) bool { return true },
+	}
+	printer := comment.Printer{TextWidth: -1}
+	return strings.TrimSpace(string(printer.Text(parser.Parse(text))))
+}
+
+// firstSentence returns the first sentence of the first paragraph of doc,
+// using the rule of godoc: a sentence ends at a period followed by a space,
+// unless the period follows a single upper-case letter as in "J. Doe".
+func firstSentence(doc string) string {
+	paragraph, _, _ := strings.Cut(doc, "\n\n")
+	paragraph = strings.Join(strings.Fields(paragraph), " ")
+
+	var ppp, pp, prev rune
+	for i, r := range paragraph {
+		if r == ' ' && prev == '.' && (!unicode.IsUpper(pp) || unicode.IsUpper(ppp)) {
+			return paragraph[:i]
+		}
+		if prev == '。' || prev == '．' {
+			return paragraph[:i]
+		}
+		ppp, pp, prev = pp, prev, r
+	}
+	return paragraph
+}
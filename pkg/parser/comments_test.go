@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"testing"
+)
+
+const docCommentSource = `package main
+
+// Config configures the server. It is read from flags.
+//
+// See [Server] for how it is used.
+// +flags-gen
+type Config struct {
+	// Addr is the [net.Listen] address. It defaults to all interfaces.
+	//
+	// Accepted forms:
+	//   - host:port
+	//   - :port
+	//
+	// See [RFC 3986] for the syntax.
+	//
+	// [RFC 3986]: https://www.rfc-editor.org/rfc/rfc3986
+	// +optional
+	Addr string
+
+	// Level is the log level, e.g. "info". Messages below it are dropped.
+	Level string ` + "`usage:\"Log level (debug, info, warn)\"`" + `
+
+	/* Workers is the number of workers.
+	   Zero means runtime.NumCPU(). */
+	Workers int
+
+	Debug bool // Debug enables debug logging. Verbose.
+
+	// Name is set by J. Doe's tooling. It is optional.
+	Name string
+}
+`
+
+func TestParser_DocComments(t *testing.T) {
+	structs, err := parseTestSource(t, docCommentSource)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if structs[0].Description != "Config configures the server." {
+		t.Errorf("Struct description = %q", structs[0].Description)
+	}
+	if expected := "Config configures the server. It is read from flags.\n\nSee Server for how it is used."; structs[0].Doc != expected {
+		t.Errorf("Struct doc = %q, expected %q", structs[0].Doc, expected)
+	}
+
+	tests := []struct {
+		description string
+		doc         string
+	}{
+		{
+			description: "Addr is the net.Listen address.",
+			doc: "Addr is the net.Listen address. It defaults to all interfaces.\n\nAccepted forms:\n  - host:port\n  - :port\n\n" +
+				"See RFC 3986 for the syntax.\n\n[RFC 3986]: https://www.rfc-editor.org/rfc/rfc3986",
+		},
+		{
+			description: "Log level (debug, info, warn)",
+			doc:         `Level is the log level, e.g. "info". Messages below it are dropped.`,
+		},
+		{
+			description: "Workers is the number of workers.",
+			doc:         "Workers is the number of workers. Zero means runtime.NumCPU().",
+		},
+		{
+			description: "Debug enables debug logging.",
+			doc:         "Debug enables debug logging. Verbose.",
+		},
+		{
+			description: "Name is set by J. Doe's tooling.",
+			doc:         "Name is set by J. Doe's tooling. It is optional.",
+		},
+	}
+
+	fields := structs[0].Fields
+	if len(fields) != len(tests) {
+		t.Fatalf("Expected %d fields, got %d", len(tests), len(fields))
+	}
+	for i, tt := range tests {
+		if fields[i].Description != tt.description {
+			t.Errorf("Field %s: description = %q, expected %q", fields[i].Name, fields[i].Description, tt.description)
+		}
+		if fields[i].Doc != tt.doc {
+			t.Errorf("Field %s: doc = %q, expected %q", fields[i].Name, fields[i].Doc, tt.doc)
+		}
+	}
+}
+
+func TestParser_DescriptionModeFull(t *testing.T) {
+	structs, err := parseTestSource(t, docCommentSource, WithDescriptionMode(FullText))
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	for _, field := range structs[0].Fields {
+		if field.Name == "Level" {
+			if field.Description != "Log level (debug, info, warn)" {
+				t.Errorf("Usage tag should win in full mode, got %q", field.Description)
+			}
+			continue
+		}
+		if field.Description != field.Doc {
+			t.Errorf("Field %s: description = %q, expected the full doc %q", field.Name, field.Description, field.Doc)
+		}
+	}
+}
+
+func TestLookupDescriptionMode(t *testing.T) {
+	if mode, err := LookupDescriptionMode("full"); err != nil || mode != FullText {
+		t.Errorf("LookupDescriptionMode(full) = %v, %v", mode, err)
+	}
+	if mode, err := LookupDescriptionMode("first-sentence"); err != nil || mode != FirstSentence {
+		t.Errorf("LookupDescriptionMode(first-sentence) = %v, %v", mode, err)
+	}
+	if _, err := LookupDescriptionMode("summary"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+// ParseDefault parses the value of a default tag for a field of one of the
+// types.SupportedTypes. The result has the field's Go type, e.g. uint32 for a
+// uint32 field or []string for a []string field. Numbers accept the same
+// formats as pflag, including base prefixes such as 0x, and are range checked
+// for the field type. Slices are comma separated; []string elements may be
+// double quoted to contain commas, as on the command line.
+func ParseDefault(value, fieldType string) (interface{}, error) {
+	switch fieldType {
+	case types.TypeString:
+		return value, nil
+	case types.TypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid bool: must be true or false", value)
+		}
+		return b, nil
+	case types.TypeInt, types.TypeInt32, types.TypeInt64:
+		i, err := strconv.ParseInt(value, 0, bitSize(fieldType))
+		if err != nil {
+			return nil, numberError(value, fieldType, err)
+		}
+		switch fieldType {
+		case types.TypeInt32:
+			return int32(i), nil
+		case types.TypeInt64:
+			return i, nil
+		default:
+			return int(i), nil
+		}
+	case "uint", "uint32", "uint64":
+		u, err := strconv.ParseUint(value, 0, bitSize(fieldType))
+		if err != nil {
+			return nil, numberError(value, fieldType, err)
+		}
+		switch fieldType {
+		case "uint32":
+			return uint32(u), nil
+		case "uint64":
+			return u, nil
+		default:
+			return uint(u), nil
+		}
+	case "float32", "float64":
+		f, err := strconv.ParseFloat(value, bitSize(fieldType))
+		if err != nil {
+			return nil, numberError(value, fieldType, err)
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("%q is not a valid %s: must be finite", value, fieldType)
+		}
+		if fieldType == "float32" {
+			return float32(f), nil
+		}
+		return f, nil
+	case types.TypeStringSlice:
+		if value == "" {
+			return []string{}, nil
+		}
+		elems, err := csv.NewReader(strings.NewReader(value)).Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				err = parseErr.Err
+			}
+			return nil, fmt.Errorf("%q is not a valid []string: %v", value, err)
+		}
+		return elems, nil
+	case "[]int":
+		if value == "" {
+			return []int{}, nil
+		}
+		elems := strings.Split(value, ",")
+		ints := make([]int, len(elems))
+		for i, elem := range elems {
+			n, err := strconv.ParseInt(strings.TrimSpace(elem), 0, 0)
+			if err != nil {
+				return nil, fmt.Errorf("element %d of %q: %w", i+1, value, numberError(strings.TrimSpace(elem), "int", err))
+			}
+			ints[i] = int(n)
+		}
+		return ints, nil
+	case types.TypeTimeDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid duration such as 30s, 1m30s or 500ms", value)
+		}
+		return d, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", fieldType)
+	}
+}
+
+// bitSize returns the size of a sized numeric type, or 0 for int and uint.
+func bitSize(fieldType string) int {
+	switch {
+	case strings.HasSuffix(fieldType, "32"):
+		return 32
+	case strings.HasSuffix(fieldType, "64"):
+		return 64
+	default:
+		return 0
+	}
+}
+
+// numberError describes a strconv error for value, naming the allowed range
+// when value does not fit fieldType.
+func numberError(value, fieldType string, err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		if low, high := numberRange(fieldType); high != "" {
+			return fmt.Errorf("%q is out of range for %s (%s to %s)", value, fieldType, low, high)
+		}
+		return fmt.Errorf("%q is out of range for %s", value, fieldType)
+	}
+	return fmt.Errorf("%q is not a valid %s", value, fieldType)
+}
+
+// numberRange returns the bounds of an integer type as strings.
+func numberRange(fieldType string) (string, string) {
+	switch fieldType {
+	case types.TypeInt:
+		return strconv.FormatInt(math.MinInt, 10), strconv.FormatInt(math.MaxInt, 10)
+	case types.TypeInt64:
+		return strconv.FormatInt(math.MinInt64, 10), strconv.FormatInt(math.MaxInt64, 10)
+	case types.TypeInt32:
+		return strconv.FormatInt(math.MinInt32, 10), strconv.FormatInt(math.MaxInt32, 10)
+	case "uint":
+		return "0", strconv.FormatUint(math.MaxUint, 10)
+	case "uint64":
+		return "0", strconv.FormatUint(math.MaxUint64, 10)
+	case "uint32":
+		return "0", strconv.FormatUint(math.MaxUint32, 10)
+	default:
+		return "", ""
+	}
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDefault(t *testing.T) {
+	tests := []struct {
+		value     string
+		fieldType string
+		expected  interface{}
+	}{
+		{"hello", "string", "hello"},
+		{"", "string", ""},
+		{"true", "bool", true},
+		{"-42", "int", -42},
+		{"0x1F", "int", 31},
+		{"2147483647", "int32", int32(2147483647)},
+		{"9223372036854775807", "int64", int64(9223372036854775807)},
+		{"8080", "uint", uint(8080)},
+		{"4294967295", "uint32", uint32(4294967295)},
+		{"18446744073709551615", "uint64", uint64(18446744073709551615)},
+		{"0.5", "float32", float32(0.5)},
+		{"1e6", "float64", 1e6},
+		{"web,api", "[]string", []string{"web", "api"}},
+		{`"a,b",c`, "[]string", []string{"a,b", "c"}},
+		{"", "[]string", []string{}},
+		{"80, 443", "[]int", []int{80, 443}},
+		{"", "[]int", []int{}},
+		{"1m30s", "time.Duration", 90 * time.Second},
+	}
+
+	for _, test := range tests {
+		result, err := ParseDefault(test.value, test.fieldType)
+		if err != nil {
+			t.Errorf("ParseDefault(%q, %s) failed: %v", test.value, test.fieldType, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("ParseDefault(%q, %s) = %#v, expected %#v", test.value, test.fieldType, result, test.expected)
+		}
+	}
+}
+
+func TestParseDefault_Errors(t *testing.T) {
+	tests := []struct {
+		value     string
+		fieldType string
+		expected  string
+	}{
+		{"yes", "bool", `"yes" is not a valid bool: must be true or false`},
+		{"eighty", "int", `"eighty" is not a valid int`},
+		{"2147483648", "int32", `"2147483648" is out of range for int32 (-2147483648 to 2147483647)`},
+		{"9223372036854775808", "int64", `"9223372036854775808" is out of range for int64`},
+		{"-1", "uint", `"-1" is not a valid uint`},
+		{"4294967296", "uint32", `"4294967296" is out of range for uint32 (0 to 4294967295)`},
+		{"1e39", "float32", `"1e39" is out of range for float32`},
+		{"NaN", "float64", `"NaN" is not a valid float64: must be finite`},
+		{`"a,b`, "[]string", `"\"a,b" is not a valid []string: extraneous or missing " in quoted-field`},
+		{"80,https", "[]int", `element 2 of "80,https": "https" is not a valid int`},
+		{"30", "time.Duration", `"30" is not a valid duration such as 30s, 1m30s or 500ms`},
+		{"x", "map[string]string", "unsupported type map[string]string"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseDefault(test.value, test.fieldType)
+		if err == nil {
+			t.Errorf("ParseDefault(%q, %s): expected error, got nil", test.value, test.fieldType)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("ParseDefault(%q, %s) error = %q, expected %q", test.value, test.fieldType, err.Error(), test.expected)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	gotypes "go/types"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultExprPrefix marks a default tag value as a Go expression, e.g. default:"=DefaultAddr".
+const defaultExprPrefix = "="
+
+// sourceFile is a parsed input file. The other files of its package and the
+// importer used to type-check them are loaded on first use by checkDefaultExpr.
+type sourceFile struct {
+	filename string
+	file     *ast.File
+	files    []*ast.File
+	importer gotypes.Importer
+}
+
+// validateDefaultExpr checks that a default-expr marker value is a Go expression.
+func validateDefaultExpr(expr string) error {
+	if _, err := parser.ParseExpr(expr); err != nil {
+		return fmt.Errorf("invalid Go expression %q: %v", expr, err)
+	}
+	return nil
+}
+
+// majorVersion matches the major version suffix of a module import path.
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// exprImports returns the imports needed by the default expression expr in
+// src. A package is either one imported by src, with its alias if it has one,
+// or a standard library package such as runtime or os. Identifiers declared
+// in src are not packages; any other identifier is assumed to be declared
+// elsewhere in the package.
+func (p *Parser) exprImports(expr string, src *sourceFile) ([]string, error) {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Go expression %q: %v", expr, err)
+	}
+
+	fileImports := make(map[string]string)
+	for _, imp := range src.file.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if imp.Name != nil {
+			fileImports[imp.Name.Name] = imp.Name.Name + " " + importPath
+			continue
+		}
+		name := path.Base(importPath)
+		if majorVersion.MatchString(name) {
+			name = path.Base(path.Dir(importPath))
+		}
+		fileImports[name] = importPath
+	}
+
+	var imports []string
+	seen := make(map[string]bool)
+	ast.Inspect(parsed, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		ident, ok := sel.X.(*ast.Ident)
+		if !ok || seen[ident.Name] {
+			return true
+		}
+		seen[ident.Name] = true
+
+		if imp, ok := fileImports[ident.Name]; ok {
+			imports = append(imports, imp)
+		} else if !declares(src.file, ident.Name) && isStdPackage(ident.Name) {
+			imports = append(imports, ident.Name)
+		}
+		return true
+	})
+	return imports, nil
+}
+
+// declares reports whether file declares name at package level.
+func declares(file *ast.File, name string) bool {
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil && decl.Name.Name == name {
+				return true
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.Name == name {
+						return true
+					}
+				case *ast.ValueSpec:
+					for _, ident := range spec.Names {
+						if ident.Name == name {
+							return true
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isStdPackage reports whether importPath is a standard library package.
+func isStdPackage(importPath string) bool {
+	pkg, err := build.Import(importPath, "", build.FindOnly)
+	return err == nil && pkg.Goroot
+}
+
+// checkDefaultExpr type-checks the default expression expr of a field of
+// fieldType, checking that it is assignable to the field. exprImports are the
+// imports returned by exprImports for expr.
+func (p *Parser) checkDefaultExpr(src *sourceFile, expr, fieldType string, exprImports []string) error {
+	if src.files == nil {
+		p.loadPackage(src)
+	}
+
+	// Check a declaration of the expression in an extra file of the package,
+	// importing what the source file and the expression use, to get the
+	// compiler's assignability and constant overflow errors.
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n\n", src.file.Name.Name)
+	for _, imp := range src.file.Imports {
+		if imp.Name != nil {
+			fmt.Fprintf(&b, "import %s %s\n", imp.Name.Name, imp.Path.Value)
+		} else {
+			fmt.Fprintf(&b, "import %s\n", imp.Path.Value)
+		}
+	}
+	for _, imp := range exprImports {
+		if name, importPath, ok := strings.Cut(imp, " "); ok {
+			fmt.Fprintf(&b, "import %s %q\n", name, importPath)
+		} else {
+			fmt.Fprintf(&b, "import %q\n", imp)
+		}
+	}
+	declLine := strings.Count(b.String(), "\n") + 1
+	fmt.Fprintf(&b, "var _ %s = %s\n", fieldType, expr)
+
+	const checkFile = "flags-gen-default-check.go"
+	file, err := parser.ParseFile(p.fileSet, checkFile, b.String(), 0)
+	if err != nil {
+		return err
+	}
+
+	// Other errors, e.g. unused or duplicate imports in the extra file or type
+	// errors in a stale generated file of the package, are ignored.
+	var checkErr error
+	conf := gotypes.Config{
+		Importer: src.importer,
+		Error: func(err error) {
+			var typeErr gotypes.Error
+			if checkErr == nil && errors.As(err, &typeErr) {
+				pos := p.fileSet.Position(typeErr.Pos)
+				if pos.Filename == checkFile && pos.Line == declLine {
+					checkErr = errors.New(strings.Replace(typeErr.Msg, " in variable declaration", "", 1))
+				}
+			}
+		},
+	}
+	_, _ = conf.Check(src.file.Name.Name, p.fileSet, append(src.files[:len(src.files):len(src.files)], file), nil)
+	return checkErr
+}
+
+// loadPackage parses the Go files of the package of src, including the
+// source file itself, and creates the importer used to type-check them.
+func (p *Parser) loadPackage(src *sourceFile) {
+	src.files = []*ast.File{src.file}
+	src.importer = importer.ForCompiler(p.fileSet, "source", nil)
+
+	dir := filepath.Dir(src.filename)
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return
+	}
+	for _, name := range pkg.GoFiles {
+		if name == filepath.Base(src.filename) {
+			continue
+		}
+		if file, err := parser.ParseFile(p.fileSet, filepath.Join(dir, name), nil, 0); err == nil {
+			src.files = append(src.files, file)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParser_DefaultExpr(t *testing.T) {
+	structs, err := parseTestSource(t, `package main
+
+import (
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+const DefaultProbeAddr = ":8081"
+
+var os = flag.NewFlagSet("app", flag.ContinueOnError)
+
+// +flags-gen
+type Config struct {
+	ProbeAddr string `+"`default:\"=DefaultProbeAddr\"`"+`
+
+	// +flags-gen:default-expr=runtime.NumCPU()
+	Workers int
+
+	Wait time.Duration `+"`default:\"= 2 * time.Minute\"`"+`
+	Name string `+"`default:\"=os.Name() + flag.CommandLine.Name()\"`"+`
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	expected := []string{"DefaultProbeAddr", "runtime.NumCPU()", "2 * time.Minute", "os.Name() + flag.CommandLine.Name()"}
+	for i, field := range structs[0].Fields {
+		if field.DefaultExpr != expected[i] || field.DefaultValueCode != expected[i] || field.DefaultValue != nil {
+			t.Errorf("Field %s: expected default expression %s, got %q (code %s, value %v)",
+				field.Name, expected[i], field.DefaultExpr, field.DefaultValueCode, field.DefaultValue)
+		}
+	}
+
+	// The os variable shadows the os package
+	imports := append([]string{}, structs[0].Imports...)
+	sort.Strings(imports)
+	expectedImports := []string{"flag github.com/spf13/pflag", "runtime", "time"}
+	if !reflect.DeepEqual(imports, expectedImports) {
+		t.Errorf("Imports = %v, expected %v", imports, expectedImports)
+	}
+}
+
+func TestParser_DefaultExprErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "empty expression",
+			source:   "type Config struct {\n\tHost string `default:\"=\"`\n}",
+			expected: `test.go:5:14: invalid default for field Host: missing Go expression after "="`,
+		},
+		{
+			name:     "invalid tag expression",
+			source:   "type Config struct {\n\tHost string `default:\"=os.Getenv(\"`\n}",
+			expected: `test.go:5:14: invalid default for field Host: invalid Go expression "os.Getenv("`,
+		},
+		{
+			name:     "invalid marker expression",
+			source:   "type Config struct {\n\t// +flags-gen:default-expr=1 +\n\tWorkers int\n}",
+			expected: `test.go:5:29: marker +flags-gen:default-expr: invalid Go expression "1 +"`,
+		},
+		{
+			name:     "marker and tag",
+			source:   "type Config struct {\n\t// +flags-gen:default-expr=runtime.NumCPU()\n\tWorkers int `default:\"4\"`\n}",
+			expected: "test.go:5:5: marker +flags-gen:default-expr conflicts with the default tag of field Workers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTestSource(t, "package main\n\n// +flags-gen\n"+tt.source)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestParser_DefaultExprTypeCheck(t *testing.T) {
+	dir := t.TempDir()
+	constants := "package main\n\nconst DefaultWorkers = 4\n\nconst DefaultName = \"app\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "constants.go"), []byte(constants), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		expected string
+	}{
+		{name: "constant in other file", field: "Workers int `default:\"=DefaultWorkers\"`"},
+		{name: "standard library call", field: "// +flags-gen:default-expr=runtime.NumCPU()\n\tWorkers int"},
+		{name: "typed duration", field: "Wait time.Duration `default:\"=DefaultWorkers * time.Second\"`"},
+		{
+			name:     "wrong type",
+			field:    "Name string `default:\"=runtime.NumCPU()\"`",
+			expected: "test.go:7:14: invalid default for field Name: cannot use runtime.NumCPU() (value of type int) as string value",
+		},
+		{
+			name:     "overflow",
+			field:    "Workers int32 `default:\"=3000000000\"`",
+			expected: "test.go:7:16: invalid default for field Workers: cannot use 3000000000 (untyped int constant) as int32 value (overflows)",
+		},
+		{
+			name:     "undefined",
+			field:    "Name string `default:\"=DefaultHost\"`",
+			expected: "test.go:7:14: invalid default for field Name: undefined: DefaultHost",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "package main\n\nimport \"time\"\n\n// +flags-gen\ntype Config struct {\n\t" + tt.field + "\n\tTimeout time.Duration\n}\n"
+			testFile := filepath.Join(dir, "test.go")
+			if err := os.WriteFile(testFile, []byte(source), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := New(WithTypeCheck(true)).ParseFile(testFile)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("ParseFile failed: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+// Kubebuilder and Kubernetes markers read as defaults and value constraints,
+// so structs that double as CRD specs need not repeat them in tags.
+const (
+	kubebuilderDefault    = "+kubebuilder:default"
+	kubernetesDefault     = "+default"
+	kubebuilderValidation = "+kubebuilder:validation:"
+)
+
+// kubeMarker is a +kubebuilder:default, +default or +kubebuilder:validation:<name> marker.
+type kubeMarker struct {
+	// name is the marker without its value, e.g. "+kubebuilder:validation:Minimum".
+	name  string
+	value string
+	pos   token.Position
+}
+
+// parseKubeMarkers returns the kubebuilder default and validation markers in
+// comment groups. Other kubebuilder markers are ignored.
+func (p *Parser) parseKubeMarkers(groups ...*ast.CommentGroup) []kubeMarker {
+	var markers []kubeMarker
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, c := range group.List {
+			text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if !strings.HasPrefix(text, "+") {
+				continue
+			}
+			name, value, _ := strings.Cut(text, "=")
+			if name != kubebuilderDefault && name != kubernetesDefault && !strings.HasPrefix(name, kubebuilderValidation) {
+				continue
+			}
+			markers = append(markers, kubeMarker{
+				name:  name,
+				value: strings.TrimSpace(value),
+				pos:   p.fileSet.Position(c.Slash + token.Pos(strings.Index(c.Text, "+"))),
+			})
+		}
+	}
+	return markers
+}
+
+// applyKubeMarkers sets the default and validation of a flag field from its
+// kubebuilder markers. A default marker must agree with a default tag.
+func (p *Parser) applyKubeMarkers(fieldInfo *types.FieldInfo, markers []kubeMarker) error {
+	for _, m := range markers {
+		errorf := func(format string, args ...interface{}) error {
+			return &PositionError{Pos: m.pos, Msg: fmt.Sprintf("invalid %s for field %s: ", m.name, fieldInfo.Name) + fmt.Sprintf(format, args...)}
+		}
+
+		if m.name == kubebuilderDefault || m.name == kubernetesDefault {
+			if fieldInfo.DefaultExpr != "" {
+				return errorf("conflicts with the default expression %s", fieldInfo.DefaultExpr)
+			}
+			value, err := parseKubeDefault(m.value, fieldInfo.Type)
+			if err != nil {
+				if !p.strict {
+					continue
+				}
+				return errorf("%v", err)
+			}
+			if _, hasTag := p.extractTag(fieldInfo.Tag, "default"); hasTag && !reflect.DeepEqual(value, fieldInfo.DefaultValue) {
+				return errorf("%s conflicts with the default tag", m.value)
+			}
+			fieldInfo.DefaultValue = value
+			fieldInfo.DefaultValueCode = p.formatDefaultValueCode(value, fieldInfo.Type)
+			continue
+		}
+
+		if fieldInfo.Validation == nil {
+			fieldInfo.Validation = &types.Validation{}
+		}
+		if err := p.applyValidation(fieldInfo, strings.TrimPrefix(m.name, kubebuilderValidation), m.value); err != nil {
+			return errorf("%v", err)
+		}
+	}
+	if fieldInfo.Validation != nil && reflect.DeepEqual(*fieldInfo.Validation, types.Validation{}) {
+		fieldInfo.Validation = nil
+	}
+	return nil
+}
+
+// applyValidation sets the constraint of a +kubebuilder:validation:<name>=<value>
+// marker. Required marks the flag required. Other markers, such as Optional,
+// Format or XValidation, are ignored, as structs that double as CRD specs need
+// them for their schema.
+func (p *Parser) applyValidation(fieldInfo *types.FieldInfo, name, value string) error {
+	validation := fieldInfo.Validation
+	fieldType := fieldInfo.Type
+	numeric := isNumeric(fieldType)
+	slice := strings.HasPrefix(fieldType, "[]")
+
+	switch name {
+	case "Minimum", "Maximum":
+		if !numeric {
+			return fmt.Errorf("applies to numeric fields, not %s", fieldType)
+		}
+		bound, err := ParseDefault(value, fieldType)
+		if err != nil {
+			return err
+		}
+		if name == "Minimum" {
+			validation.Minimum = p.formatDefaultValueCode(bound, fieldType)
+		} else {
+			validation.Maximum = p.formatDefaultValueCode(bound, fieldType)
+		}
+	case "ExclusiveMinimum", "ExclusiveMaximum":
+		exclusive := true
+		if value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%q must be true or false", value)
+			}
+			exclusive = b
+		}
+		if name == "ExclusiveMinimum" {
+			validation.ExclusiveMinimum = exclusive
+		} else {
+			validation.ExclusiveMaximum = exclusive
+		}
+	case "MinLength", "MaxLength", "MinItems", "MaxItems":
+		isLength := strings.HasSuffix(name, "Length")
+		if isLength && fieldType != types.TypeString {
+			return fmt.Errorf("applies to string fields, not %s", fieldType)
+		}
+		if !isLength && !slice {
+			return fmt.Errorf("applies to slice fields, not %s", fieldType)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%q is not a non-negative integer", value)
+		}
+		switch name {
+		case "MinLength":
+			validation.MinLength = &n
+		case "MaxLength":
+			validation.MaxLength = &n
+		case "MinItems":
+			validation.MinItems = &n
+		default:
+			validation.MaxItems = &n
+		}
+	case "Pattern":
+		if fieldType != types.TypeString {
+			return fmt.Errorf("applies to string fields, not %s", fieldType)
+		}
+		pattern, err := unquoteMarkerValue(value)
+		if err != nil {
+			return err
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+		validation.Pattern = pattern
+	case "Enum":
+		if fieldType != types.TypeString && !numeric {
+			return fmt.Errorf("applies to string and numeric fields, not %s", fieldType)
+		}
+		for _, elem := range splitOutsideQuotes(value, ';') {
+			s, err := unquoteMarkerValue(strings.TrimSpace(elem))
+			if err != nil {
+				return err
+			}
+			parsed, err := ParseDefault(s, fieldType)
+			if err != nil {
+				return err
+			}
+			validation.Enum = append(validation.Enum, p.formatDefaultValueCode(parsed, fieldType))
+		}
+	case "Required":
+		fieldInfo.Required = true
+	}
+	return nil
+}
+
+// parseKubeDefault parses the value of a kubebuilder default marker, a JSON or
+// Go literal such as 8080, "localhost" or ["a","b"] ({a,b} for slices in the
+// kubebuilder shorthand).
+func parseKubeDefault(value, fieldType string) (interface{}, error) {
+	if !strings.HasPrefix(fieldType, "[]") {
+		s, err := unquoteMarkerValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return ParseDefault(s, fieldType)
+	}
+
+	inner, ok := strings.CutPrefix(value, "[")
+	if ok {
+		inner, ok = strings.CutSuffix(inner, "]")
+	} else if inner, ok = strings.CutPrefix(value, "{"); ok {
+		inner, ok = strings.CutSuffix(inner, "}")
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s is not a list such as [\"a\",\"b\"] or {a,b}", value)
+	}
+
+	var elems []string
+	if strings.TrimSpace(inner) != "" {
+		for _, elem := range splitMarkerList(inner) {
+			s, err := unquoteMarkerValue(strings.TrimSpace(elem))
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, s)
+		}
+	}
+
+	if fieldType == types.TypeStringSlice {
+		if elems == nil {
+			return []string{}, nil
+		}
+		return elems, nil
+	}
+	ints := make([]int, len(elems))
+	for i, elem := range elems {
+		n, err := ParseDefault(elem, types.TypeInt)
+		if err != nil {
+			return nil, fmt.Errorf("element %d of %s: %w", i+1, value, err)
+		}
+		ints[i] = n.(int)
+	}
+	return ints, nil
+}
+
+// isNumeric reports whether fieldType is an integer or floating-point type.
+func isNumeric(fieldType string) bool {
+	switch fieldType {
+	case "int", "int32", "int64", "uint", "uint32", "uint64", "float32", "float64":
+		return true
+	default:
+		return false
+	}
+}
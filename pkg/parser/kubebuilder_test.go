@@ -0,0 +1,210 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+func TestParser_KubebuilderDefaults(t *testing.T) {
+	structs, err := parseTestSource(t, `package main
+
+import "time"
+
+// +flags-gen
+type Spec struct {
+	// Port is the server port
+	// +kubebuilder:default=8080
+	// +optional
+	Port int32 `+"`json:\"port,omitempty\"`"+`
+
+	// +kubebuilder:default="localhost"
+	Host string
+
+	// +default="30s"
+	Timeout time.Duration
+
+	// +kubebuilder:default={web,"a,b"}
+	Tags []string
+
+	// +default=[80,443]
+	Ports []int
+
+	// +kubebuilder:default=true
+	Debug bool `+"`default:\"true\"`"+`
+
+	// Template is not a flag
+	// +kubebuilder:default={}
+	Template map[string]string `+"`flag:\"-\"`"+`
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	expected := []string{"8080", `"localhost"`, "30*time.Second", `[]string{"web", "a,b"}`, "[]int{80, 443}", "true"}
+	fields := structs[0].Fields
+	if len(fields) != len(expected) {
+		t.Fatalf("Expected %d fields, got %d", len(expected), len(fields))
+	}
+	for i, field := range fields {
+		if field.DefaultValueCode != expected[i] {
+			t.Errorf("Field %s: expected default code %s, got %s", field.Name, expected[i], field.DefaultValueCode)
+		}
+	}
+	if fields[0].Description != "Port is the server port" {
+		t.Errorf("Markers leaked into description: %q", fields[0].Description)
+	}
+}
+
+func TestParser_KubebuilderValidation(t *testing.T) {
+	structs, err := parseTestSource(t, `package main
+
+// +flags-gen
+type Spec struct {
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:validation:ExclusiveMaximum=true
+	Port int
+
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`+"`^[a-z]+$`"+`
+	Name string
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=debug;info;"warn"
+	Level string
+
+	// +kubebuilder:validation:MaxItems=3
+	Tags []string
+
+	// +kubebuilder:validation:Optional
+	Debug bool
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	one, three, sixtyThree := 1, 3, 63
+	expected := []*types.Validation{
+		{Minimum: "1", Maximum: "65535", ExclusiveMaximum: true},
+		{MinLength: &one, MaxLength: &sixtyThree, Pattern: "^[a-z]+$"},
+		{Enum: []string{`"debug"`, `"info"`, `"warn"`}},
+		{MaxItems: &three},
+		nil,
+	}
+	for i, field := range structs[0].Fields {
+		if !reflect.DeepEqual(field.Validation, expected[i]) {
+			t.Errorf("Field %s: expected validation %+v, got %+v", field.Name, expected[i], field.Validation)
+		}
+		if field.Required != (field.Name == "Level") {
+			t.Errorf("Field %s: required = %v", field.Name, field.Required)
+		}
+	}
+	if !structs[0].HasValidation() {
+		t.Error("Expected HasValidation to be true")
+	}
+	if !structs[0].HasConstraints() {
+		t.Error("Expected HasConstraints to be true for a required flag")
+	}
+}
+
+func TestParser_KubebuilderSchemaMarkers(t *testing.T) {
+	// Markers only meaningful for the CRD schema are ignored, even in strict mode
+	structs, err := parseTestSource(t, `package main
+
+// +flags-gen
+type Spec struct {
+	// +kubebuilder:validation:Format=hostname
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:XValidation:rule="self != ''",message="must not be empty"
+	Name string
+
+	// +kubebuilder:validation:MultipleOf=2
+	// +kubebuilder:validation:Nullable
+	Replicas int
+
+	// +kubebuilder:validation:UniqueItems=true
+	// +kubebuilder:validation:Schemaless
+	Tags []string
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	for _, field := range structs[0].Fields {
+		if field.FlagMethod == "" || field.Validation != nil || field.Required {
+			t.Errorf("Expected a plain flag for field %s, got %+v", field.Name, field)
+		}
+	}
+}
+
+func TestParser_KubebuilderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "invalid default",
+			source:   "type Spec struct {\n\t// +kubebuilder:default=eighty\n\tPort int\n}",
+			expected: `test.go:5:5: invalid +kubebuilder:default for field Port: "eighty" is not a valid int`,
+		},
+		{
+			name:     "conflicting default tag",
+			source:   "type Spec struct {\n\t// +kubebuilder:default=8080\n\tPort int `default:\"80\"`\n}",
+			expected: "test.go:5:5: invalid +kubebuilder:default for field Port: 8080 conflicts with the default tag",
+		},
+		{
+			name:     "default and default expression",
+			source:   "type Spec struct {\n\t// +default=8080\n\tPort int `default:\"=DefaultPort\"`\n}",
+			expected: "test.go:5:5: invalid +default for field Port: conflicts with the default expression DefaultPort",
+		},
+		{
+			name:     "invalid list default",
+			source:   "type Spec struct {\n\t// +kubebuilder:default=a,b\n\tTags []string\n}",
+			expected: `test.go:5:5: invalid +kubebuilder:default for field Tags: a,b is not a list such as ["a","b"] or {a,b}`,
+		},
+		{
+			name:     "minimum on string",
+			source:   "type Spec struct {\n\t// +kubebuilder:validation:Minimum=1\n\tName string\n}",
+			expected: "test.go:5:5: invalid +kubebuilder:validation:Minimum for field Name: applies to numeric fields, not string",
+		},
+		{
+			name:     "minimum out of range",
+			source:   "type Spec struct {\n\t// +kubebuilder:validation:Minimum=-1\n\tSize uint32\n}",
+			expected: `test.go:5:5: invalid +kubebuilder:validation:Minimum for field Size: "-1" is not a valid uint32`,
+		},
+		{
+			name:     "invalid pattern",
+			source:   "type Spec struct {\n\t// +kubebuilder:validation:Pattern=`[a-z`\n\tName string\n}",
+			expected: "test.go:5:5: invalid +kubebuilder:validation:Pattern for field Name: invalid pattern: error parsing regexp",
+		},
+		{
+			name:     "negative length",
+			source:   "type Spec struct {\n\t// +kubebuilder:validation:MaxLength=-1\n\tName string\n}",
+			expected: `test.go:5:5: invalid +kubebuilder:validation:MaxLength for field Name: "-1" is not a non-negative integer`,
+		},
+		{
+			name:     "items on string",
+			source:   "type Spec struct {\n\t// +kubebuilder:validation:MinItems=1\n\tName string\n}",
+			expected: "test.go:5:5: invalid +kubebuilder:validation:MinItems for field Name: applies to slice fields, not string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTestSource(t, "package main\n\n// +flags-gen\n"+tt.source)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
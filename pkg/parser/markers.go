@@ -0,0 +1,416 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuvalwz/flags-gen/pkg/types"
+)
+
+// markerPrefix starts every flags-gen marker. A bare "+flags-gen" above a
+// struct enables generation; "+flags-gen:<name>[=<value>]" configures it.
+const markerPrefix = "+flags-gen"
+
+// markerTarget is where a marker may appear.
+type markerTarget int
+
+const (
+	fieldMarker markerTarget = iota
+	structMarker
+)
+
+func (t markerTarget) String() string {
+	if t == structMarker {
+		return "structs"
+	}
+	return "fields"
+}
+
+// markerArg is the kind of argument a marker takes.
+type markerArg int
+
+const (
+	// boolArg markers take no value or an explicit =true/=false.
+	boolArg markerArg = iota
+	// stringArg markers take a bare or Go-quoted string: name=value, name="a value".
+	stringArg
+	// listArg markers take comma-separated bare or Go-quoted strings: name=a,b,"c".
+	listArg
+)
+
+// markerDef describes a supported marker.
+type markerDef struct {
+	target     markerTarget
+	arg        markerArg
+	repeatable bool
+	validate   func(value string) error
+}
+
+// markerDefs lists the supported markers by name.
+var markerDefs = map[string]markerDef{
+	// Field markers
+	"name":       {target: fieldMarker, arg: stringArg, validate: validateFlagName},
+	"short":      {target: fieldMarker, arg: stringArg, validate: validateShortFlag},
+	"hidden":     {target: fieldMarker, arg: boolArg},
+	"deprecated": {target: fieldMarker, arg: stringArg},
+	"alias":      {target: fieldMarker, arg: listArg, repeatable: true, validate: validateFlagName},
+
+	"shorthand-deprecated": {target: fieldMarker, arg: stringArg},
+	"env":                  {target: fieldMarker, arg: stringArg, validate: validateEnvVar},
+	"skip":                 {target: fieldMarker, arg: boolArg},
+	"group":                {target: fieldMarker, arg: stringArg},
+	"complete":             {target: fieldMarker, arg: stringArg, validate: validateCompletion},
+	"sensitive":            {target: fieldMarker, arg: boolArg},
+	"from-file":            {target: fieldMarker, arg: boolArg},
+	"default-expr":         {target: fieldMarker, arg: stringArg, validate: validateDefaultExpr},
+
+	// Struct markers
+	"prefix": {target: structMarker, arg: stringArg},
+	"method": {target: structMarker, arg: stringArg, validate: validateMethodName},
+
+	"with-prefix": {target: structMarker, arg: boolArg},
+	"provenance":  {target: structMarker, arg: boolArg},
+	"config-file": {target: structMarker, arg: boolArg},
+	"watch":       {target: structMarker, arg: boolArg},
+
+	"mutually-exclusive": {target: structMarker, arg: listArg, repeatable: true},
+	"required-together":  {target: structMarker, arg: listArg, repeatable: true},
+	"one-required":       {target: structMarker, arg: listArg, repeatable: true},
+}
+
+// marker is a parsed +flags-gen:<name>[=<value>] marker.
+type marker struct {
+	name  string
+	value string
+	list  []string
+	set   bool
+	pos   token.Position
+}
+
+// markerSet holds the markers found in one comment group.
+type markerSet struct {
+	// annotated is true when the group contains any flags-gen marker.
+	annotated bool
+	markers   []marker
+}
+
+// lookup returns the first marker with the given name.
+func (s markerSet) lookup(name string) (marker, bool) {
+	for _, m := range s.markers {
+		if m.name == name {
+			return m, true
+		}
+	}
+	return marker{}, false
+}
+
+// getList returns the values of all list markers with the given name.
+func (s markerSet) getList(name string) []string {
+	var values []string
+	for _, m := range s.markers {
+		if m.name == name {
+			values = append(values, m.list...)
+		}
+	}
+	return values
+}
+
+// all returns every marker with the given name, in source order.
+func (s markerSet) all(name string) []marker {
+	var markers []marker
+	for _, m := range s.markers {
+		if m.name == name {
+			markers = append(markers, m)
+		}
+	}
+	return markers
+}
+
+// has reports whether a boolean marker is set.
+func (s markerSet) has(name string) bool {
+	for _, m := range s.markers {
+		if m.name == name {
+			return m.set
+		}
+	}
+	return false
+}
+
+// get returns the value of a string marker.
+func (s markerSet) get(name string) (string, bool) {
+	for _, m := range s.markers {
+		if m.name == name {
+			return m.value, true
+		}
+	}
+	return "", false
+}
+
+// PositionError is an error tied to a position in the parsed source.
+type PositionError struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *PositionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// parseMarkers extracts the flags-gen markers from comment groups, checking that
+// each one is known, allowed on target and has a well-formed argument.
+func (p *Parser) parseMarkers(target markerTarget, groups ...*ast.CommentGroup) (markerSet, error) {
+	var set markerSet
+
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, c := range group.List {
+			offset := 0
+			for _, line := range strings.Split(c.Text, "\n") {
+				lineOffset := offset
+				offset += len(line) + 1
+
+				idx := strings.Index(line, markerPrefix)
+				if idx < 0 || strings.TrimLeft(line[:idx], "/* \t") != "" {
+					continue
+				}
+
+				text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line[idx:]), "*/"))
+				rest := text[len(markerPrefix):]
+				if rest != "" && rest[0] != ':' && rest[0] != ' ' && rest[0] != '\t' {
+					// Some other marker such as +flags-generator
+					continue
+				}
+
+				set.annotated = true
+				if rest == "" || rest[0] != ':' {
+					continue
+				}
+
+				pos := c.Slash + token.Pos(lineOffset+idx)
+				m, err := p.parseMarker(target, rest[1:], pos)
+				if err != nil {
+					return set, err
+				}
+
+				def := markerDefs[m.name]
+				if !def.repeatable {
+					for _, existing := range set.markers {
+						if existing.name == m.name {
+							return set, p.errorf(pos, "duplicate marker %s:%s (first set at %s)", markerPrefix, m.name, existing.pos)
+						}
+					}
+				}
+				set.markers = append(set.markers, m)
+			}
+		}
+	}
+
+	return set, nil
+}
+
+// parseMarker parses the "<name>[=<value>]" part of a marker starting at pos.
+func (p *Parser) parseMarker(target markerTarget, text string, pos token.Pos) (marker, error) {
+	name, value, hasValue := strings.Cut(text, "=")
+	name = strings.TrimSpace(name)
+	m := marker{name: name, pos: p.fileSet.Position(pos)}
+
+	def, ok := markerDefs[name]
+	if !ok {
+		return m, p.errorf(pos, "unknown marker %s:%s (known markers: %s)", markerPrefix, name, strings.Join(markerNames(), ", "))
+	}
+	if def.target != target {
+		return m, p.errorf(pos, "marker %s:%s applies to %s, not %s", markerPrefix, name, def.target, target)
+	}
+
+	// Position of the value for argument errors
+	valuePos := pos + token.Pos(len(markerPrefix)+1+len(text)-len(value))
+	value = strings.TrimSpace(value)
+
+	switch def.arg {
+	case boolArg:
+		if !hasValue {
+			m.set = true
+			break
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return m, p.errorf(valuePos, "marker %s:%s expects true or false, got %q", markerPrefix, name, value)
+		}
+		m.set = b
+	case stringArg:
+		s, err := unquoteMarkerValue(value)
+		if err != nil {
+			return m, p.errorf(valuePos, "marker %s:%s: %v", markerPrefix, name, err)
+		}
+		if !hasValue || s == "" {
+			return m, p.errorf(pos, "marker %s:%s requires a value, e.g. %s:%s=<value>", markerPrefix, name, markerPrefix, name)
+		}
+		if def.validate != nil {
+			if err := def.validate(s); err != nil {
+				return m, p.errorf(valuePos, "marker %s:%s: %v", markerPrefix, name, err)
+			}
+		}
+		m.value = s
+	case listArg:
+		if !hasValue || value == "" {
+			return m, p.errorf(pos, "marker %s:%s requires a comma-separated list, e.g. %s:%s=a,b", markerPrefix, name, markerPrefix, name)
+		}
+		for _, elem := range splitMarkerList(value) {
+			s, err := unquoteMarkerValue(strings.TrimSpace(elem))
+			if err != nil {
+				return m, p.errorf(valuePos, "marker %s:%s: %v", markerPrefix, name, err)
+			}
+			if s == "" {
+				return m, p.errorf(valuePos, "marker %s:%s has an empty list element", markerPrefix, name)
+			}
+			if def.validate != nil {
+				if err := def.validate(s); err != nil {
+					return m, p.errorf(valuePos, "marker %s:%s: %v", markerPrefix, name, err)
+				}
+			}
+			m.list = append(m.list, s)
+		}
+	}
+
+	return m, nil
+}
+
+// errorf returns a PositionError at pos.
+func (p *Parser) errorf(pos token.Pos, format string, args ...interface{}) error {
+	return &PositionError{Pos: p.fileSet.Position(pos), Msg: fmt.Sprintf(format, args...)}
+}
+
+// markerNames returns the names of all supported markers, sorted.
+func markerNames() []string {
+	names := make([]string, 0, len(markerDefs))
+	for name := range markerDefs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unquoteMarkerValue unquotes a Go-quoted marker value, returning bare values as is.
+func unquoteMarkerValue(value string) (string, error) {
+	if !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "`") {
+		return value, nil
+	}
+	s, err := strconv.Unquote(value)
+	if err != nil {
+		return "", fmt.Errorf("malformed quoted value %s", value)
+	}
+	return s, nil
+}
+
+// splitMarkerList splits a list value on commas outside of quotes.
+func splitMarkerList(value string) []string {
+	return splitOutsideQuotes(value, ',')
+}
+
+// splitOutsideQuotes splits value on sep outside of double quotes.
+func splitOutsideQuotes(value string, sep byte) []string {
+	var elems []string
+	start := 0
+	inQuote := false
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			if inQuote {
+				i++
+			}
+		case '"':
+			inQuote = !inQuote
+		case sep:
+			if !inQuote {
+				elems = append(elems, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(elems, value[start:])
+}
+
+var envVarPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateFlagName checks that name can be used as a long flag name.
+func validateFlagName(name string) error {
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("flag name %q must not start with a dash", name)
+	}
+	if strings.ContainsAny(name, " \t\n=") {
+		return fmt.Errorf("flag name %q must not contain whitespace or '='", name)
+	}
+	return nil
+}
+
+// validateShortFlag checks that short is a single ASCII letter or digit.
+func validateShortFlag(short string) error {
+	r, size := utf8.DecodeRuneInString(short)
+	if size != len(short) || r > utf8.RuneSelf || !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+		return fmt.Errorf("short flag %q must be a single ASCII letter or digit", short)
+	}
+	return nil
+}
+
+// validateEnvVar checks that name is a valid environment variable name.
+func validateEnvVar(name string) error {
+	if !envVarPattern.MatchString(name) {
+		return fmt.Errorf("environment variable name %q must match %s", name, envVarPattern)
+	}
+	return nil
+}
+
+// validateCompletion checks a completion spec: files[:<ext>,...], dirs or func:<Name>.
+func validateCompletion(spec string) error {
+	_, err := parseCompletion(spec)
+	return err
+}
+
+// parseCompletion parses a +flags-gen:complete value.
+func parseCompletion(spec string) (*types.Completion, error) {
+	kind, arg, hasArg := strings.Cut(spec, ":")
+	completion := &types.Completion{Kind: kind}
+
+	switch kind {
+	case types.CompleteFiles:
+		if !hasArg {
+			break
+		}
+		for _, pattern := range strings.Split(arg, ",") {
+			ext := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(pattern), "*"), ".")
+			if ext == "" || strings.ContainsAny(ext, "*?/[]") {
+				return nil, fmt.Errorf("invalid file pattern %q, expected *.<ext> or <ext>", pattern)
+			}
+			completion.Extensions = append(completion.Extensions, ext)
+		}
+	case types.CompleteDirs:
+		if hasArg {
+			return nil, fmt.Errorf("completion %q takes no argument", kind)
+		}
+	case types.CompleteFunc:
+		if !token.IsIdentifier(arg) {
+			return nil, fmt.Errorf("completion func:%s must name a function in the same package", arg)
+		}
+		completion.Func = arg
+	default:
+		return nil, fmt.Errorf("unknown completion %q (expected files[:<ext>,...], dirs or func:<Name>)", spec)
+	}
+
+	return completion, nil
+}
+
+// validateMethodName checks that name is an exported Go identifier.
+func validateMethodName(name string) error {
+	if !token.IsIdentifier(name) || !ast.IsExported(name) {
+		return fmt.Errorf("method name %q must be an exported Go identifier", name)
+	}
+	return nil
+}
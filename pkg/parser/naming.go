@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultInitialisms lists the acronyms recognized as single words when
+// splitting identifiers into flag names, e.g. "HTTPPort" -> "http-port"
+// and "RequiredCRDs" -> "required-crds".
+var DefaultInitialisms = []string{
+	"ACL", "API", "ASCII", "CA", "CIDR", "CPU", "CRD", "CSS", "DNS", "EOF",
+	"GID", "GRPC", "GUID", "HTML", "HTTP", "HTTPS", "ID", "IP", "JSON", "JWT",
+	"LHS", "OIDC", "QPS", "RAM", "RBAC", "RHS", "RPC", "SLA", "SMTP", "SQL",
+	"SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID", "URI", "URL", "UUID",
+	"VM", "XML", "XMPP", "XSRF", "XSS", "YAML",
+}
+
+// wordSplitter splits Go identifiers and json tags into words.
+type wordSplitter struct {
+	// initialisms holds upper-case acronyms, longest first.
+	initialisms []string
+}
+
+// newWordSplitter creates a wordSplitter recognizing the default initialisms
+// plus any extra ones.
+func newWordSplitter(extra []string) *wordSplitter {
+	seen := make(map[string]bool)
+	var initialisms []string
+	for _, list := range [][]string{DefaultInitialisms, extra} {
+		for _, s := range list {
+			s = strings.ToUpper(strings.TrimSpace(s))
+			if s == "" || seen[s] {
+				continue
+			}
+			seen[s] = true
+			initialisms = append(initialisms, s)
+		}
+	}
+
+	sort.SliceStable(initialisms, func(i, j int) bool {
+		return len(initialisms[i]) > len(initialisms[j])
+	})
+
+	return &wordSplitter{initialisms: initialisms}
+}
+
+// split breaks s into words at case changes and at any non-alphanumeric
+// separator. Runs of capitals are split into known initialisms, and an
+// initialism followed by a lone "s" (e.g. "CRDs") is kept as one plural word.
+func (w *wordSplitter) split(s string) []string {
+	runes := []rune(s)
+	var words []string
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			// Separator such as '-', '_' or '.'
+			i++
+		case !unicode.IsUpper(r):
+			j := i
+			for j < len(runes) && isLowerOrDigit(runes[j]) {
+				j++
+			}
+			words = append(words, string(runes[i:j]))
+			i = j
+		default:
+			// Find the run of capitals starting at i
+			j := i
+			for j < len(runes) && unicode.IsUpper(runes[j]) {
+				j++
+			}
+
+			if n := w.matchInitialism(runes, i, j); n > 0 {
+				words = append(words, string(runes[i:i+n]))
+				i += n
+				continue
+			}
+
+			end := j
+			if j-i > 1 && j < len(runes) && unicode.IsLower(runes[j]) {
+				// The last capital starts the next word (e.g. XMLParser -> XML, Parser)
+				end = j - 1
+			} else if j-i == 1 {
+				// A single capital starts a regular word (e.g. Probe)
+				for end < len(runes) && isLowerOrDigit(runes[end]) {
+					end++
+				}
+			}
+			words = append(words, string(runes[i:end]))
+			i = end
+		}
+	}
+
+	return words
+}
+
+// matchInitialism returns the length of the known initialism starting at runes[i]
+// within the run of capitals runes[i:j], or 0 if none matches at a word boundary.
+func (w *wordSplitter) matchInitialism(runes []rune, i, j int) int {
+	for _, initialism := range w.initialisms {
+		n := len([]rune(initialism))
+		end := i + n
+		if end > j || string(runes[i:end]) != initialism {
+			continue
+		}
+
+		// More capitals follow, so the next word starts right after the initialism
+		if end < j {
+			return n
+		}
+
+		// Plural initialism such as "CRDs" or "IDs"
+		if end < len(runes) && runes[end] == 's' && (end+1 == len(runes) || !unicode.IsLower(runes[end+1])) {
+			return n + 1
+		}
+
+		if end == len(runes) || !unicode.IsLower(runes[end]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// isLowerOrDigit reports whether r continues a lower-case word.
+func isLowerOrDigit(r rune) bool {
+	return unicode.IsLower(r) || unicode.IsDigit(r)
+}
+
+// NameParts describes the identifier a flag name is derived from.
+type NameParts struct {
+	// GoName is the Go field name, e.g. "MetricsAddr".
+	GoName string
+	// JSONTag is the json tag name, empty when the field has none.
+	JSONTag string
+	// Words is JSONTag, or GoName when untagged, split into words with their
+	// original case, e.g. ["metrics", "Addr"].
+	Words []string
+	// PrefixWords is the struct's +flags-gen:prefix split into words, to be
+	// placed before Words. It is empty when the struct has no prefix.
+	PrefixWords []string
+}
+
+// NamingStrategy derives a flag name from a struct field.
+type NamingStrategy interface {
+	FlagName(parts NameParts) string
+}
+
+// NamingStrategyFunc adapts a function to the NamingStrategy interface.
+type NamingStrategyFunc func(parts NameParts) string
+
+// FlagName calls f(parts).
+func (f NamingStrategyFunc) FlagName(parts NameParts) string {
+	return f(parts)
+}
+
+// Built-in naming strategies.
+var (
+	// KebabCase joins lower-cased words with dashes: --metrics-addr.
+	KebabCase NamingStrategy = joinWords("-")
+	// SnakeCase joins lower-cased words with underscores: --metrics_addr.
+	SnakeCase NamingStrategy = joinWords("_")
+	// DotCase joins lower-cased words with dots: --metrics.addr.
+	DotCase NamingStrategy = joinWords(".")
+	// CamelCase joins words in lowerCamelCase, keeping initialisms upper-case: --metricsAddr.
+	CamelCase NamingStrategy = NamingStrategyFunc(camelCase)
+	// JSONVerbatim uses the json tag as is, or the Go field name when untagged.
+	JSONVerbatim NamingStrategy = NamingStrategyFunc(jsonVerbatim)
+)
+
+// NamingStrategies maps the names accepted by LookupNamingStrategy to the
+// built-in strategies.
+var NamingStrategies = map[string]NamingStrategy{
+	"kebab":         KebabCase,
+	"snake":         SnakeCase,
+	"camel":         CamelCase,
+	"dot":           DotCase,
+	"json-verbatim": JSONVerbatim,
+}
+
+// NamingStrategyNames returns the names of the built-in strategies, sorted.
+func NamingStrategyNames() []string {
+	names := make([]string, 0, len(NamingStrategies))
+	for name := range NamingStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupNamingStrategy returns the built-in strategy with the given name.
+func LookupNamingStrategy(name string) (NamingStrategy, error) {
+	strategy, ok := NamingStrategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown naming strategy %q (supported: %s)", name, strings.Join(NamingStrategyNames(), ", "))
+	}
+	return strategy, nil
+}
+
+// joinWords returns a strategy joining lower-cased words with sep.
+func joinWords(sep string) NamingStrategy {
+	return NamingStrategyFunc(func(parts NameParts) string {
+		return strings.ToLower(strings.Join(parts.allWords(), sep))
+	})
+}
+
+// camelCase joins words in lowerCamelCase. Upper-case words such as
+// initialisms keep their case after the first word, e.g. "requiredCRDs".
+func camelCase(parts NameParts) string {
+	var b strings.Builder
+	for i, word := range parts.allWords() {
+		if i == 0 {
+			b.WriteString(strings.ToLower(word))
+			continue
+		}
+		if strings.ToUpper(strings.TrimSuffix(word, "s")) == strings.TrimSuffix(word, "s") {
+			b.WriteString(word)
+			continue
+		}
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// jsonVerbatim returns the json tag, falling back to the Go field name as encoding/json does.
+// A prefix is prepended in camelCase, e.g. "serverMetricsAddr".
+func jsonVerbatim(parts NameParts) string {
+	name := parts.JSONTag
+	if name == "" {
+		name = parts.GoName
+	}
+	if len(parts.PrefixWords) == 0 {
+		return name
+	}
+
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return camelCase(NameParts{Words: parts.PrefixWords}) + string(runes)
+}
+
+// allWords returns the prefix words followed by the name words.
+func (parts NameParts) allWords() []string {
+	return append(append([]string{}, parts.PrefixWords...), parts.Words...)
+}
@@ -9,33 +9,124 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	gotypes "go/types"
+	"io"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/yuvalwz/flags-gen/pkg/types"
 )
 
 // Parser handles parsing Go source files for structs with flags-gen annotations.
 type Parser struct {
-	fileSet *token.FileSet
+	fileSet         *token.FileSet
+	initialisms     []string
+	words           *wordSplitter
+	naming          NamingStrategy
+	strict          bool
+	typeCheck       bool
+	descriptionMode DescriptionMode
+}
+
+// Option configures a Parser.
+type Option func(*Parser)
+
+// WithInitialisms adds acronyms to the built-in DefaultInitialisms used when
+// deriving flag names, e.g. "PVC" turns "BoundPVCsLimit" into "bound-pvcs-limit".
+func WithInitialisms(initialisms ...string) Option {
+	return func(p *Parser) {
+		p.initialisms = append(p.initialisms, initialisms...)
+	}
+}
+
+// WithNamingStrategy sets the strategy used to derive flag names. The default is KebabCase.
+func WithNamingStrategy(strategy NamingStrategy) Option {
+	return func(p *Parser) {
+		p.naming = strategy
+	}
+}
+
+// WithStrict sets whether an exported field with an unsupported type or an
+// invalid default fails parsing. The default is true; with strict mode off such
+// fields are kept without a FlagMethod, or without a DefaultValue respectively.
+func WithStrict(strict bool) Option {
+	return func(p *Parser) {
+		p.strict = strict
+	}
+}
+
+// WithTypeCheck sets whether default expressions are type-checked against
+// their field types. This loads and type-checks the package of the parsed file
+// and the packages it imports, so it is off by default.
+func WithTypeCheck(typeCheck bool) Option {
+	return func(p *Parser) {
+		p.typeCheck = typeCheck
+	}
 }
 
 // New creates a new Parser instance.
-func New() *Parser {
-	return &Parser{
+func New(opts ...Option) *Parser {
+	p := &Parser{
 		fileSet: token.NewFileSet(),
+		naming:  KebabCase,
+		strict:  true,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.words = newWordSplitter(p.initialisms)
+	return p
+}
+
+// WithFileSet sets the FileSet positions are recorded in. ASTs passed to
+// ParseAST must have been parsed with this FileSet for their positions to be
+// reported correctly.
+func WithFileSet(fileSet *token.FileSet) Option {
+	return func(p *Parser) {
+		p.fileSet = fileSet
 	}
 }
 
 // ParseFile parses a Go source file and returns structs marked with +flags-gen.
 func (p *Parser) ParseFile(filename string) ([]types.StructInfo, error) {
-	src, err := parser.ParseFile(p.fileSet, filename, nil, parser.ParseComments)
+	return p.ParseSource(filename, nil)
+}
+
+// ParseSource parses Go source code and returns structs marked with
+// +flags-gen, for files that are not saved, such as editor buffers. The
+// filename is used in positions and, when type-checking, to find the other
+// files of the package. A nil src reads the file from disk.
+func (p *Parser) ParseSource(filename string, src []byte) ([]types.StructInfo, error) {
+	var source interface{}
+	if src != nil {
+		source = src
+	}
+	file, err := parser.ParseFile(p.fileSet, filename, source, parser.ParseComments)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse file %s: %w", filename, err)
 	}
+	return p.ParseAST(file)
+}
+
+// ParseReader parses Go source code read from r, like ParseSource.
+func (p *Parser) ParseReader(filename string, r io.Reader) ([]types.StructInfo, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+	return p.ParseSource(filename, src)
+}
 
+// ParseAST returns the structs marked with +flags-gen in an already parsed
+// file. The file must have been parsed with parser.ParseComments, and with the
+// Parser's FileSet (see WithFileSet) for positions to be correct.
+func (p *Parser) ParseAST(src *ast.File) ([]types.StructInfo, error) {
 	var structs []types.StructInfo
+	file := &sourceFile{filename: p.fileSet.Position(src.Package).Filename, file: src}
 
 	// Walk through all declarations in the file
 	for _, decl := range src.Decls {
@@ -44,13 +135,23 @@ func (p *Parser) ParseFile(filename string) ([]types.StructInfo, error) {
 				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
 					if structType, ok := typeSpec.Type.(*ast.StructType); ok {
 						// Check if this struct has the +flags-gen annotation
-						if p.hasAnnotation(genDecl.Doc) {
-							structInfo, err := p.parseStruct(typeSpec.Name.Name, structType, src.Name.Name)
-							if err != nil {
-								return nil, fmt.Errorf("failed to parse struct %s: %w", typeSpec.Name.Name, err)
-							}
-							structs = append(structs, structInfo)
+						if !p.hasAnnotation(genDecl.Doc) {
+							continue
 						}
+
+						markers, err := p.parseMarkers(structMarker, genDecl.Doc)
+						if err != nil {
+							return nil, fmt.Errorf("failed to parse struct %s: %w", typeSpec.Name.Name, err)
+						}
+
+						structInfo, err := p.parseStruct(typeSpec.Name.Name, structType, file, markers)
+						if err != nil {
+							return nil, fmt.Errorf("failed to parse struct %s: %w", typeSpec.Name.Name, err)
+						}
+						structInfo.Doc = p.parseDoc(nil, genDecl.Doc)
+						structInfo.Description = p.description(structInfo.Doc)
+						structInfo.Pos = p.position(typeSpec.Name.Pos())
+						structs = append(structs, structInfo)
 					}
 				}
 			}
@@ -60,29 +161,43 @@ func (p *Parser) ParseFile(filename string) ([]types.StructInfo, error) {
 	return structs, nil
 }
 
+// position converts pos to a types.Position.
+func (p *Parser) position(pos token.Pos) types.Position {
+	position := p.fileSet.Position(pos)
+	return types.Position{Filename: position.Filename, Line: position.Line, Column: position.Column}
+}
+
 // hasAnnotation checks if the comment group contains +flags-gen annotation.
 func (p *Parser) hasAnnotation(commentGroup *ast.CommentGroup) bool {
 	if commentGroup == nil {
 		return false
 	}
 
-	for _, comment := range commentGroup.List {
-		if strings.Contains(comment.Text, "+flags-gen") {
-			return true
-		}
-	}
-	return false
+	markers, err := p.parseMarkers(structMarker, commentGroup)
+	return err != nil || markers.annotated
 }
 
 // parseStruct parses a struct and extracts field information for flag generation.
-func (p *Parser) parseStruct(name string, structType *ast.StructType, packageName string) (types.StructInfo, error) {
+func (p *Parser) parseStruct(name string, structType *ast.StructType, src *sourceFile, markers markerSet) (types.StructInfo, error) {
 	structInfo := types.StructInfo{
 		Name:        name,
-		PackageName: packageName,
+		PackageName: src.file.Name.Name,
+		MethodName:  types.DefaultMethodName,
 		Fields:      make([]types.FieldInfo, 0),
 		Imports:     make([]string, 0),
 	}
 
+	if prefix, ok := markers.get("prefix"); ok {
+		structInfo.Prefix = prefix
+	}
+	if method, ok := markers.get("method"); ok {
+		structInfo.MethodName = method
+	}
+	structInfo.WithPrefix = markers.has("with-prefix")
+	structInfo.Provenance = markers.has("provenance")
+	structInfo.Watch = markers.has("watch")
+	structInfo.ConfigFile = markers.has("config-file") || structInfo.Watch
+
 	imports := make(map[string]bool)
 
 	for _, field := range structType.Fields.List {
@@ -91,16 +206,33 @@ func (p *Parser) parseStruct(name string, structType *ast.StructType, packageNam
 			continue
 		}
 
+		markers, err := p.parseMarkers(fieldMarker, field.Doc, field.Comment)
+		if err != nil {
+			return structInfo, fmt.Errorf("failed to parse field %s: %w", field.Names[0].Name, err)
+		}
+		if markers.has("skip") {
+			continue
+		}
+		if field.Tag != nil {
+			if flagName, ok := p.extractTag(tagValue(field.Tag), "flag"); ok && flagName == "-" {
+				continue
+			}
+		}
+
 		for _, fieldName := range field.Names {
 			// Skip unexported fields
 			if !ast.IsExported(fieldName.Name) {
 				continue
 			}
 
-			fieldInfo, err := p.parseField(fieldName.Name, field)
+			fieldInfo, err := p.parseField(fieldName.Name, field, structInfo.Prefix)
 			if err != nil {
 				return structInfo, fmt.Errorf("failed to parse field %s: %w", fieldName.Name, err)
 			}
+			if err := p.applyFieldMarkers(&fieldInfo, markers); err != nil {
+				return structInfo, fmt.Errorf("failed to parse field %s: %w", fieldName.Name, err)
+			}
+			fieldInfo.Pos = p.position(fieldName.Pos())
 
 			// Add required imports based on field type
 			if fieldInfo.Type == types.TypeTimeDuration {
@@ -111,22 +243,147 @@ func (p *Parser) parseStruct(name string, structType *ast.StructType, packageNam
 			if method, exists := types.GetFlagMethod(fieldInfo.Type); exists {
 				fieldInfo.FlagMethod = method
 				fieldInfo.DefaultValueCode = p.formatDefaultValueCode(fieldInfo.DefaultValue, fieldInfo.Type)
+			} else if p.strict {
+				return structInfo, p.errorf(fieldName.Pos(), "field %s has unsupported type %s: %s; add %s:skip or a flag:\"-\" tag to leave it without a flag",
+					fieldName.Name, fieldInfo.Type, suggestType(fieldInfo.Type), markerPrefix)
+			}
+
+			if fieldInfo.FlagMethod != "" {
+				if err := p.applyKubeMarkers(&fieldInfo, p.parseKubeMarkers(field.Doc, field.Comment)); err != nil {
+					return structInfo, err
+				}
+			}
+
+			if fieldInfo.DefaultExpr != "" && fieldInfo.FlagMethod != "" {
+				// Report errors at the marker or the default tag
+				exprPos := p.fileSet.Position(fieldName.Pos())
+				if m, ok := markers.lookup("default-expr"); ok {
+					exprPos = m.pos
+				} else if field.Tag != nil {
+					exprPos = p.fileSet.Position(field.Tag.Pos())
+				}
+
+				exprImports, err := p.exprImports(fieldInfo.DefaultExpr, src)
+				if err != nil {
+					return structInfo, &PositionError{Pos: exprPos, Msg: fmt.Sprintf("invalid default for field %s: %v", fieldName.Name, err)}
+				}
+				for _, imp := range exprImports {
+					imports[imp] = true
+				}
+				if p.typeCheck {
+					if err := p.checkDefaultExpr(src, fieldInfo.DefaultExpr, fieldInfo.Type, exprImports); err != nil {
+						return structInfo, &PositionError{Pos: exprPos, Msg: fmt.Sprintf("invalid default for field %s: %v", fieldName.Name, err)}
+					}
+				}
+				fieldInfo.DefaultValueCode = fieldInfo.DefaultExpr
 			}
 
 			structInfo.Fields = append(structInfo.Fields, fieldInfo)
 		}
 	}
 
-	// Convert imports map to slice
+	if err := p.parseConstraints(&structInfo, markers); err != nil {
+		return structInfo, err
+	}
+
+	// Convert imports map to slice, sorted for stable output
 	for imp := range imports {
 		structInfo.Imports = append(structInfo.Imports, imp)
 	}
+	sort.Strings(structInfo.Imports)
 
 	return structInfo, nil
 }
 
+// parseConstraints collects the cobra flag constraint markers of a struct,
+// checking that every flag they name is declared by the struct.
+func (p *Parser) parseConstraints(structInfo *types.StructInfo, markers markerSet) error {
+	flagNames := make(map[string]bool)
+	for i := range structInfo.Fields {
+		if structInfo.Fields[i].FlagMethod != "" {
+			flagNames[structInfo.Fields[i].FlagName] = true
+		}
+	}
+
+	constraints := []struct {
+		name  string
+		flags *[][]string
+	}{
+		{"mutually-exclusive", &structInfo.MutuallyExclusive},
+		{"required-together", &structInfo.RequiredTogether},
+		{"one-required", &structInfo.OneRequired},
+	}
+
+	for _, constraint := range constraints {
+		for _, m := range markers.all(constraint.name) {
+			if len(m.list) < 2 {
+				return &PositionError{Pos: m.pos, Msg: fmt.Sprintf("marker %s:%s needs at least two flags", markerPrefix, m.name)}
+			}
+			for _, name := range m.list {
+				if !flagNames[name] {
+					return &PositionError{Pos: m.pos, Msg: fmt.Sprintf("marker %s:%s refers to unknown flag %q (flags of %s: %s)",
+						markerPrefix, m.name, name, structInfo.Name, strings.Join(sortedKeys(flagNames), ", "))}
+				}
+			}
+			*constraint.flags = append(*constraint.flags, m.list)
+		}
+	}
+
+	return nil
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// applyFieldMarkers copies field marker values into fieldInfo.
+func (p *Parser) applyFieldMarkers(fieldInfo *types.FieldInfo, markers markerSet) error {
+	if name, ok := markers.get("name"); ok {
+		fieldInfo.FlagName = name
+	}
+	if short, ok := markers.get("short"); ok {
+		fieldInfo.ShortFlag = short
+	}
+	if msg, ok := markers.get("deprecated"); ok {
+		fieldInfo.Deprecated = msg
+	}
+	if env, ok := markers.get("env"); ok {
+		fieldInfo.EnvVar = env
+	}
+	if group, ok := markers.get("group"); ok {
+		fieldInfo.Group = group
+	}
+	if spec, ok := markers.get("complete"); ok {
+		// Already validated while parsing the marker
+		fieldInfo.Completion, _ = parseCompletion(spec)
+	}
+	if m, ok := markers.lookup("shorthand-deprecated"); ok {
+		if fieldInfo.ShortFlag == "" {
+			return &PositionError{Pos: m.pos, Msg: fmt.Sprintf("marker %s:shorthand-deprecated requires a %s:short shorthand", markerPrefix, markerPrefix)}
+		}
+		fieldInfo.ShorthandDeprecated = m.value
+	}
+	fieldInfo.Aliases = markers.getList("alias")
+	fieldInfo.Hidden = markers.has("hidden")
+	fieldInfo.Sensitive = fieldInfo.Sensitive || markers.has("sensitive")
+	fieldInfo.FromFile = markers.has("from-file")
+	if m, ok := markers.lookup("default-expr"); ok {
+		if _, hasTag := p.extractTag(fieldInfo.Tag, "default"); hasTag {
+			return &PositionError{Pos: m.pos, Msg: fmt.Sprintf("marker %s:default-expr conflicts with the default tag of field %s", markerPrefix, fieldInfo.Name)}
+		}
+		fieldInfo.DefaultExpr = m.value
+	}
+	return nil
+}
+
 // parseField extracts information from a single struct field.
-func (p *Parser) parseField(name string, field *ast.Field) (types.FieldInfo, error) {
+func (p *Parser) parseField(name string, field *ast.Field, prefix string) (types.FieldInfo, error) {
 	fieldInfo := types.FieldInfo{
 		Name: name,
 	}
@@ -140,18 +397,45 @@ func (p *Parser) parseField(name string, field *ast.Field) (types.FieldInfo, err
 
 	// Parse struct tags
 	if field.Tag != nil {
-		tag := strings.Trim(field.Tag.Value, "`")
+		tag := tagValue(field.Tag)
+		fieldInfo.Tag = tag
 		fieldInfo.JSONTag = p.extractJSONTag(tag)
-		fieldInfo.FlagName = p.deriveFlagName(name, fieldInfo.JSONTag)
+		fieldInfo.FlagName = p.deriveFlagName(name, fieldInfo.JSONTag, prefix)
+
+		// An explicit flag tag wins over the derived name
+		if flagName, ok := p.extractTag(tag, "flag"); ok && flagName != "" {
+			fieldInfo.FlagName = flagName
+		}
+
+		// Look for default values in tags. Fields of unsupported types get no
+		// flag, so their default is not parsed.
+		if value, ok := p.extractTag(tag, "default"); ok && strings.HasPrefix(value, defaultExprPrefix) {
+			fieldInfo.DefaultExpr = strings.TrimSpace(strings.TrimPrefix(value, defaultExprPrefix))
+			if fieldInfo.DefaultExpr == "" {
+				return fieldInfo, p.errorf(field.Tag.Pos(), "invalid default for field %s: missing Go expression after %q", name, defaultExprPrefix)
+			}
+		} else if _, supported := types.GetFlagMethod(fieldType); supported {
+			fieldInfo.DefaultValue, err = p.extractDefaultFromTag(tag, fieldType)
+			if err != nil && p.strict {
+				return fieldInfo, p.errorf(field.Tag.Pos(), "invalid default for field %s: %v", name, err)
+			}
+		}
 
-		// Look for default values in tags
-		fieldInfo.DefaultValue = p.extractDefaultFromTag(tag, fieldType)
+		if sensitive, ok := p.extractTag(tag, "sensitive"); ok {
+			if fieldInfo.Sensitive, err = strconv.ParseBool(sensitive); err != nil {
+				return fieldInfo, p.errorf(field.Tag.Pos(), "invalid sensitive tag %q: must be true or false", sensitive)
+			}
+		}
 	} else {
-		fieldInfo.FlagName = p.deriveFlagName(name, "")
+		fieldInfo.FlagName = p.deriveFlagName(name, "", prefix)
 	}
 
-	// Parse field comments for description
-	fieldInfo.Description = p.parseFieldComment(field.Comment, field.Doc)
+	// Parse field comments for description. A usage tag wins over the doc comment.
+	fieldInfo.Doc = p.parseDoc(field.Comment, field.Doc)
+	fieldInfo.Description = p.description(fieldInfo.Doc)
+	if usage, ok := p.extractTag(fieldInfo.Tag, "usage"); ok {
+		fieldInfo.Description = usage
+	}
 
 	return fieldInfo, nil
 }
@@ -168,16 +452,76 @@ func (p *Parser) parseType(expr ast.Expr) (string, error) {
 		}
 		return pkg + "." + t.Sel.Name, nil
 	case *ast.ArrayType:
+		if t.Len != nil {
+			return gotypes.ExprString(expr), nil
+		}
 		elemType, err := p.parseType(t.Elt)
 		if err != nil {
 			return "", err
 		}
 		return "[]" + elemType, nil
 	default:
-		return "", fmt.Errorf("unsupported type: %T", expr)
+		// Render other types such as maps and pointers as written, they have no flag method
+		return gotypes.ExprString(expr), nil
 	}
 }
 
+// suggestType returns a suggestion for replacing the unsupported fieldType with a
+// supported one, such as the element type of a pointer or a close spelling.
+func suggestType(fieldType string) string {
+	candidate := strings.TrimPrefix(fieldType, "*")
+	if strings.HasPrefix(candidate, "[") && !strings.HasPrefix(candidate, "[]") {
+		// Fixed-length array
+		if i := strings.Index(candidate, "]"); i > 0 {
+			candidate = "[]" + candidate[i+1:]
+		}
+	}
+	switch candidate {
+	case "int8", "int16":
+		candidate = "int32"
+	case "uint8", "uint16":
+		candidate = "uint32"
+	case "Duration":
+		candidate = types.TypeTimeDuration
+	}
+	if _, ok := types.GetFlagMethod(candidate); ok && candidate != fieldType {
+		return fmt.Sprintf("use %s instead", candidate)
+	}
+
+	supported := make([]string, 0, len(types.SupportedTypes))
+	for name := range types.SupportedTypes {
+		supported = append(supported, name)
+	}
+	sort.Strings(supported)
+	for _, name := range supported {
+		if editDistance(fieldType, name) <= 2 {
+			return fmt.Sprintf("did you mean %s?", name)
+		}
+	}
+	return "supported types are " + strings.Join(supported, ", ")
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
 // extractJSONTag extracts the json tag value from struct tag.
 func (p *Parser) extractJSONTag(tag string) string {
 	re := regexp.MustCompile(`json:"([^"]*)"`)
@@ -192,98 +536,53 @@ func (p *Parser) extractJSONTag(tag string) string {
 	return ""
 }
 
-// extractDefaultFromTag extracts default values from struct tags.
-func (p *Parser) extractDefaultFromTag(tag, fieldType string) interface{} {
-	re := regexp.MustCompile(`default:"([^"]*)"`)
-	matches := re.FindStringSubmatch(tag)
-	if len(matches) > 1 {
-		defaultStr := matches[1]
-		return p.parseDefaultValue(defaultStr, fieldType)
-	}
-	return nil
+// extractTag returns the value of the given key in a struct tag.
+func (p *Parser) extractTag(tag, key string) (string, bool) {
+	return reflect.StructTag(tag).Lookup(key)
 }
 
-// parseDefaultValue converts string default value to appropriate type.
-func (p *Parser) parseDefaultValue(value, fieldType string) interface{} {
-	switch fieldType {
-	case types.TypeString:
-		return value
-	case types.TypeInt, types.TypeInt32, types.TypeInt64:
-		if i, err := strconv.Atoi(value); err == nil {
-			return i
-		}
-	case types.TypeBool:
-		if b, err := strconv.ParseBool(value); err == nil {
-			return b
-		}
-	case types.TypeStringSlice:
-		if value != "" {
-			return strings.Split(value, ",")
-		}
-		return []string{}
-	case types.TypeTimeDuration:
-		return value // Keep as string, will be parsed later
+// tagValue returns the value of a struct tag literal, which is a raw or an
+// interpreted string literal.
+func tagValue(tag *ast.BasicLit) string {
+	value, err := strconv.Unquote(tag.Value)
+	if err != nil {
+		return ""
 	}
 	return value
 }
 
-// deriveFlagName creates a flag name from field name and json tag.
-func (p *Parser) deriveFlagName(fieldName, jsonTag string) string {
-	if jsonTag != "" {
-		return p.toKebabCase(jsonTag)
+// extractDefaultFromTag extracts default values from struct tags.
+func (p *Parser) extractDefaultFromTag(tag, fieldType string) (interface{}, error) {
+	if value, ok := p.extractTag(tag, "default"); ok {
+		return p.parseDefaultValue(value, fieldType)
 	}
-	return p.toKebabCase(fieldName)
+	return nil, nil
 }
 
-// toKebabCase converts camelCase to kebab-case.
-func (p *Parser) toKebabCase(s string) string {
-	// Handle sequences of capital letters (e.g., HTTPPort -> HTTP-Port)
-	re1 := regexp.MustCompile(`([A-Z]+)([A-Z][a-z])`)
-	result := re1.ReplaceAllString(s, "${1}-${2}")
-
-	// Handle normal camelCase (e.g., camelCase -> camel-Case)
-	re2 := regexp.MustCompile(`([a-z])([A-Z])`)
-	result = re2.ReplaceAllString(result, "${1}-${2}")
-
-	return strings.ToLower(result)
+// parseDefaultValue converts string default value to appropriate type.
+func (p *Parser) parseDefaultValue(value, fieldType string) (interface{}, error) {
+	return ParseDefault(value, fieldType)
 }
 
-// parseFieldComment extracts description from field comments.
-func (p *Parser) parseFieldComment(comment, doc *ast.CommentGroup) string {
-	var description string
-
-	// Check doc comment first (appears before the field)
-	if doc != nil {
-		for _, c := range doc.List {
-			text := strings.TrimPrefix(c.Text, "//")
-			text = strings.TrimPrefix(text, "/*")
-			text = strings.TrimSuffix(text, "*/")
-			text = strings.TrimSpace(text)
-
-			// Skip annotations like +optional
-			if strings.HasPrefix(text, "+") {
-				continue
-			}
-
-			if description == "" {
-				description = text
-			} else {
-				description += " " + text
-			}
-		}
-	}
-
-	// Check inline comment if no doc comment found
-	if description == "" && comment != nil {
-		for _, c := range comment.List {
-			text := strings.TrimPrefix(c.Text, "//")
-			text = strings.TrimSpace(text)
-			description = text
-			break // Only take the first inline comment
-		}
+// deriveFlagName creates a flag name from field name, json tag and struct
+// prefix using the naming strategy.
+func (p *Parser) deriveFlagName(fieldName, jsonTag, prefix string) string {
+	source := fieldName
+	if jsonTag != "" {
+		source = jsonTag
 	}
+	return p.naming.FlagName(NameParts{
+		GoName:      fieldName,
+		JSONTag:     jsonTag,
+		Words:       p.words.split(source),
+		PrefixWords: p.words.split(prefix),
+	})
+}
 
-	return description
+// toKebabCase converts camelCase to kebab-case, keeping known initialisms
+// (including plurals such as CRDs) together as one word.
+func (p *Parser) toKebabCase(s string) string {
+	return strings.ToLower(strings.Join(p.words.split(s), "-"))
 }
 
 // formatDefaultValueCode formats a default value for code generation.
@@ -304,11 +603,30 @@ func (p *Parser) formatDefaultValueCode(value interface{}, fieldType string) str
 			return fmt.Sprintf("[]string{%s}", strings.Join(quoted, ", "))
 		}
 		return `[]string{}`
+	case "[]int":
+		if slice, ok := value.([]int); ok {
+			elems := make([]string, len(slice))
+			for i, n := range slice {
+				elems[i] = strconv.Itoa(n)
+			}
+			return fmt.Sprintf("[]int{%s}", strings.Join(elems, ", "))
+		}
+		return `[]int{}`
 	case types.TypeTimeDuration:
-		if str, ok := value.(string); ok {
-			return fmt.Sprintf("%s*time.Second", strings.TrimSuffix(str, "s"))
+		if d, ok := value.(time.Duration); ok {
+			return types.DurationCode(d)
 		}
 		return "0"
+	case "float32":
+		if f, ok := value.(float32); ok {
+			return strconv.FormatFloat(float64(f), 'g', -1, 32)
+		}
+		return fmt.Sprintf("%v", value)
+	case "float64":
+		if f, ok := value.(float64); ok {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return fmt.Sprintf("%v", value)
 	default:
 		return fmt.Sprintf("%v", value)
 	}
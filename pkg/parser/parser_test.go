@@ -1,9 +1,13 @@
 package parser
 
 import (
+	goparser "go/parser"
+	"go/token"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/yuvalwz/flags-gen/pkg/types"
 )
@@ -115,6 +119,78 @@ type IgnoredConfig struct {
 	}
 }
 
+const inMemorySource = `package config
+
+import "time"
+
+var os = "shadowed"
+
+// +flags-gen
+type Config struct {
+	// Host is the server host.
+	Host string ` + "`default:\"localhost\"`" + `
+
+	// Wait is the startup delay.
+	// +flags-gen:default-expr=2 * time.Second
+	Wait time.Duration
+
+	// Name is taken from a variable, not the os package.
+	Name string ` + "`default:\"=os\"`" + `
+}
+`
+
+func TestParser_ParseSource(t *testing.T) {
+	// The file does not exist on disk
+	filename := filepath.Join(t.TempDir(), "config.go")
+
+	structs, err := New().ParseSource(filename, []byte(inMemorySource))
+	if err != nil {
+		t.Fatalf("ParseSource failed: %v", err)
+	}
+	if len(structs) != 1 || len(structs[0].Fields) != 3 {
+		t.Fatalf("Expected 1 struct with 3 fields, got %+v", structs)
+	}
+	if pos := structs[0].Fields[0].Pos; pos.Filename != filename || pos.Line != 10 {
+		t.Errorf("Host position = %s:%d, expected %s:10", pos.Filename, pos.Line, filename)
+	}
+
+	structs, err = New().ParseReader(filename, strings.NewReader(inMemorySource))
+	if err != nil {
+		t.Fatalf("ParseReader failed: %v", err)
+	}
+	if structs[0].Fields[0].DefaultValueCode != `"localhost"` {
+		t.Errorf("Host default code = %s", structs[0].Fields[0].DefaultValueCode)
+	}
+
+	_, err = New().ParseSource(filename, []byte("package config\n\ntype Config struct {"))
+	if err == nil || !strings.Contains(err.Error(), "failed to parse file "+filename) {
+		t.Errorf("Expected syntax error for %s, got %v", filename, err)
+	}
+}
+
+func TestParser_ParseAST(t *testing.T) {
+	fileSet := token.NewFileSet()
+	// Object resolution is not needed to tell variables from packages
+	file, err := goparser.ParseFile(fileSet, "config.go", inMemorySource, goparser.ParseComments|goparser.SkipObjectResolution)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	structs, err := New(WithFileSet(fileSet)).ParseAST(file)
+	if err != nil {
+		t.Fatalf("ParseAST failed: %v", err)
+	}
+	if len(structs) != 1 || len(structs[0].Fields) != 3 {
+		t.Fatalf("Expected 1 struct with 3 fields, got %+v", structs)
+	}
+	if pos := structs[0].Pos; pos.Filename != "config.go" || pos.Line != 8 || pos.Column != 6 {
+		t.Errorf("Config position = %+v, expected config.go:8:6", pos)
+	}
+	if imports := structs[0].Imports; len(imports) != 1 || imports[0] != "time" {
+		t.Errorf("Imports = %v, expected [time]", imports)
+	}
+}
+
 func TestParser_toKebabCase(t *testing.T) {
 	parser := New()
 
@@ -127,6 +203,35 @@ func TestParser_toKebabCase(t *testing.T) {
 		{"V", "v"},
 		{"HTTPPort", "http-port"},
 		{"XMLParser", "xml-parser"},
+		{"RequiredCRDs", "required-crds"},
+		{"RequiredCRDsGracePeriod", "required-crds-grace-period"},
+		{"requiredCRDs", "required-crds"},
+		{"UserIDs", "user-ids"},
+		{"TLSConfig", "tls-config"},
+		{"APIServerURL", "api-server-url"},
+		{"ABCParser", "abc-parser"},
+		{"database-url", "database-url"},
+		{"metrics_addr", "metrics-addr"},
+	}
+
+	for _, test := range tests {
+		result := parser.toKebabCase(test.input)
+		if result != test.expected {
+			t.Errorf("toKebabCase(%s) = %s, expected %s", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestParser_toKebabCase_CustomInitialisms(t *testing.T) {
+	parser := New(WithInitialisms("pvc"))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"BoundPVCsLimit", "bound-pvcs-limit"},
+		{"PVCName", "pvc-name"},
+		{"HTTPPort", "http-port"},
 	}
 
 	for _, test := range tests {
@@ -137,6 +242,74 @@ func TestParser_toKebabCase(t *testing.T) {
 	}
 }
 
+func TestParser_NamingStrategies(t *testing.T) {
+	tests := []struct {
+		strategy  string
+		fieldName string
+		jsonTag   string
+		expected  string
+	}{
+		{"kebab", "MetricsAddr", "metricsAddr", "metrics-addr"},
+		{"snake", "MetricsAddr", "metricsAddr", "metrics_addr"},
+		{"dot", "MetricsAddr", "metricsAddr", "metrics.addr"},
+		{"camel", "MetricsAddr", "metricsAddr", "metricsAddr"},
+		{"camel", "HTTPPort", "", "httpPort"},
+		{"camel", "RequiredCRDs", "", "requiredCRDs"},
+		{"snake", "RequiredCRDsGracePeriod", "", "required_crds_grace_period"},
+		{"json-verbatim", "MetricsAddr", "metrics_addr", "metrics_addr"},
+		{"json-verbatim", "MetricsAddr", "", "MetricsAddr"},
+	}
+
+	for _, test := range tests {
+		strategy, err := LookupNamingStrategy(test.strategy)
+		if err != nil {
+			t.Fatalf("LookupNamingStrategy(%s) failed: %v", test.strategy, err)
+		}
+		result := New(WithNamingStrategy(strategy)).deriveFlagName(test.fieldName, test.jsonTag, "")
+		if result != test.expected {
+			t.Errorf("%s: deriveFlagName(%s, %q) = %s, expected %s", test.strategy, test.fieldName, test.jsonTag, result, test.expected)
+		}
+	}
+
+	if _, err := LookupNamingStrategy("pascal"); err == nil {
+		t.Error("Expected an error for an unknown naming strategy")
+	}
+}
+
+func TestParser_FlagTagOverride(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "flags-gen-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	testFile := filepath.Join(tmpDir, "test.go")
+	testContent := `package main
+
+// +flags-gen
+type Config struct {
+	MetricsAddr string ` + "`json:\"metricsAddr\" flag:\"metrics-bind-address\"`" + `
+	ProbeAddr string ` + "`json:\"probeAddr\"`" + `
+}
+`
+
+	if err := os.WriteFile(testFile, []byte(testContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	structs, err := New().ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if got := structs[0].Fields[0].FlagName; got != "metrics-bind-address" {
+		t.Errorf("Expected flag tag to override json tag, got %q", got)
+	}
+	if got := structs[0].Fields[1].FlagName; got != "probe-addr" {
+		t.Errorf("Expected flag name derived from json tag, got %q", got)
+	}
+}
+
 func TestParser_hasAnnotation(_ *testing.T) {
 	// This would need more complex AST setup to test properly
 	// For now, we test it indirectly through ParseFile
@@ -181,11 +354,17 @@ func TestParser_parseDefaultValue(t *testing.T) {
 		{"false", "bool", false},
 		{"web,api", "[]string", []string{"web", "api"}},
 		{"", "[]string", []string{}},
-		{"30s", "time.Duration", "30s"},
+		{"30s", "time.Duration", 30 * time.Second},
+		{"1m30s", "time.Duration", 90 * time.Second},
+		{"500ms", "time.Duration", 500 * time.Millisecond},
 	}
 
 	for _, test := range tests {
-		result := parser.parseDefaultValue(test.value, test.fieldType)
+		result, err := parser.parseDefaultValue(test.value, test.fieldType)
+		if err != nil {
+			t.Errorf("parseDefaultValue(%s, %s) failed: %v", test.value, test.fieldType, err)
+			continue
+		}
 		switch expected := test.expected.(type) {
 		case []string:
 			if resultSlice, ok := result.([]string); !ok {
@@ -206,3 +385,430 @@ func TestParser_parseDefaultValue(t *testing.T) {
 		}
 	}
 }
+
+func TestParser_DurationDefaults(t *testing.T) {
+	structs, err := parseTestSource(t, `package main
+
+import "time"
+
+// +flags-gen
+type Config struct {
+	Timeout  time.Duration `+"`default:\"1m30s\"`"+`
+	Interval time.Duration `+"`default:\"500ms\"`"+`
+	TTL      time.Duration `+"`default:\"2h\"`"+`
+	Backoff  time.Duration `+"`default:\"1.5s\"`"+`
+	Tick     time.Duration `+"`default:\"1h0m0.000000001s\"`"+`
+	Zero     time.Duration `+"`default:\"0s\"`"+`
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	expected := []string{"90*time.Second", "500*time.Millisecond", "2*time.Hour", "1500*time.Millisecond", "3600000000001*time.Nanosecond", "0"}
+	for i, field := range structs[0].Fields {
+		if field.DefaultValueCode != expected[i] {
+			t.Errorf("Field %s: expected default code %s, got %s", field.Name, expected[i], field.DefaultValueCode)
+		}
+	}
+
+	_, err = parseTestSource(t, "package main\n\nimport \"time\"\n\n// +flags-gen\ntype Config struct {\n\tTimeout time.Duration `default:\"30 seconds\"`\n}\n")
+	expectedErr := `test.go:7:24: invalid default for field Timeout: "30 seconds" is not a valid duration such as 30s, 1m30s or 500ms`
+	if err == nil || !strings.Contains(err.Error(), expectedErr) {
+		t.Errorf("Expected error containing %q, got %v", expectedErr, err)
+	}
+}
+
+func TestParser_TypedDefaults(t *testing.T) {
+	source := "package main\n\n// +flags-gen\ntype Config struct {\n" +
+		"\tSize uint32 `default:\"0x10\"`\n" +
+		"\tRatio float64 `default:\"0.25\"`\n" +
+		"\tPorts []int `default:\"80,443\"`\n" +
+		"\tTags []string `default:\"\\\"a,b\\\",c\"`\n" +
+		"}\n"
+	structs, err := parseTestSource(t, source)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	expected := []string{"16", "0.25", "[]int{80, 443}", `[]string{"a,b", "c"}`}
+	for i, field := range structs[0].Fields {
+		if field.DefaultValueCode != expected[i] {
+			t.Errorf("Field %s: expected default code %s, got %s", field.Name, expected[i], field.DefaultValueCode)
+		}
+	}
+
+	invalid := "package main\n\n// +flags-gen\ntype Config struct {\n\tWorkers int32 `default:\"3000000000\"`\n}\n"
+	_, err = parseTestSource(t, invalid)
+	expectedErr := `test.go:5:16: invalid default for field Workers: "3000000000" is out of range for int32 (-2147483648 to 2147483647)`
+	if err == nil || !strings.Contains(err.Error(), expectedErr) {
+		t.Errorf("Expected error containing %q, got %v", expectedErr, err)
+	}
+
+	// Without strict mode an invalid default falls back to the zero value
+	structs, err = parseTestSource(t, invalid, WithStrict(false))
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if field := structs[0].Fields[0]; field.DefaultValue != nil || field.DefaultValueCode != "0" {
+		t.Errorf("Expected zero default, got %v (%s)", field.DefaultValue, field.DefaultValueCode)
+	}
+}
+
+// parseTestSource writes content to a temporary file and parses it.
+func parseTestSource(t *testing.T, content string, opts ...Option) ([]types.StructInfo, error) {
+	t.Helper()
+
+	testFile := filepath.Join(t.TempDir(), "test.go")
+	if err := os.WriteFile(testFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return New(opts...).ParseFile(testFile)
+}
+
+func TestParser_Markers(t *testing.T) {
+	structs, err := parseTestSource(t, `package main
+
+// ServerConfig defines server configuration
+// +flags-gen
+// +flags-gen:prefix=server
+// +flags-gen:method=RegisterFlags
+// +flags-gen:with-prefix
+// +flags-gen:provenance
+// +flags-gen:watch
+type ServerConfig struct {
+	// Host is the server hostname
+	// +flags-gen:short=H
+	// +flags-gen:env=SERVER_HOST
+	Host string `+"`json:\"host\"`"+`
+
+	// +flags-gen:name=listen-port
+	// +flags-gen:group=Network
+	Port int `+"`json:\"port\"`"+`
+
+	// +flags-gen:hidden
+	// +flags-gen:deprecated="use --listen-port instead"
+	// +flags-gen:short=p
+	// +flags-gen:shorthand-deprecated="use --listen-port instead"
+	// +flags-gen:alias=old-port-number,legacy-port
+	// +flags-gen:alias=ancient-port
+	OldPort int `+"`json:\"oldPort\"`"+`
+
+	// +flags-gen:skip
+	Internal string `+"`json:\"internal\"`"+`
+
+	Debug bool // +flags-gen:hidden=false
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	config := structs[0]
+	if config.Prefix != "server" || config.MethodName != "RegisterFlags" || !config.WithPrefix {
+		t.Errorf("Struct markers not applied: prefix=%q method=%q with-prefix=%v", config.Prefix, config.MethodName, config.WithPrefix)
+	}
+	if !config.Provenance || !config.Watch || !config.ConfigFile {
+		t.Errorf("Runtime markers not applied (watch implies config-file): provenance=%v watch=%v config-file=%v", config.Provenance, config.Watch, config.ConfigFile)
+	}
+	if config.Description != "ServerConfig defines server configuration" {
+		t.Errorf("Markers should not be part of the struct description: %q", config.Description)
+	}
+	if len(config.Fields) != 4 {
+		t.Fatalf("Expected 4 fields (Internal is skipped), got %d", len(config.Fields))
+	}
+
+	if config.Pos.Line != 10 || config.Pos.Column != 6 || !strings.HasSuffix(config.Pos.Filename, "test.go") {
+		t.Errorf("Unexpected struct position %s", config.Pos)
+	}
+
+	host := config.Fields[0]
+	if host.Pos.Line != 14 || host.Pos.Column != 2 {
+		t.Errorf("Unexpected field position %s", host.Pos)
+	}
+	if host.FlagName != "server-host" || host.ShortFlag != "H" || host.EnvVar != "SERVER_HOST" {
+		t.Errorf("Host markers not applied: %+v", host)
+	}
+	if host.Description != "Host is the server hostname" {
+		t.Errorf("Markers should not be part of the field description: %q", host.Description)
+	}
+
+	port := config.Fields[1]
+	if port.FlagName != "listen-port" || port.Group != "Network" {
+		t.Errorf("Port markers not applied: %+v", port)
+	}
+
+	oldPort := config.Fields[2]
+	if !oldPort.Hidden || oldPort.Deprecated != "use --listen-port instead" || oldPort.ShorthandDeprecated != "use --listen-port instead" {
+		t.Errorf("OldPort markers not applied: %+v", oldPort)
+	}
+	if strings.Join(oldPort.Aliases, ",") != "old-port-number,legacy-port,ancient-port" {
+		t.Errorf("Expected aliases from repeated alias markers, got %v", oldPort.Aliases)
+	}
+
+	if debug := config.Fields[3]; debug.Hidden {
+		t.Errorf("Expected +flags-gen:hidden=false to leave Debug visible")
+	}
+}
+
+func TestParser_MarkerErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "unknown field marker",
+			source:   "type Config struct {\n\t// +flags-gen:hiden\n\tHost string\n}",
+			expected: "test.go:5:5: unknown marker +flags-gen:hiden",
+		},
+		{
+			name:     "struct marker on field",
+			source:   "type Config struct {\n\t// +flags-gen:prefix=x\n\tHost string\n}",
+			expected: "test.go:5:5: marker +flags-gen:prefix applies to structs, not fields",
+		},
+		{
+			name:     "field marker on struct",
+			source:   "// +flags-gen:hidden\ntype Config struct {\n\tHost string\n}",
+			expected: "test.go:4:4: marker +flags-gen:hidden applies to fields, not structs",
+		},
+		{
+			name:     "missing value",
+			source:   "type Config struct {\n\t// +flags-gen:env\n\tHost string\n}",
+			expected: "test.go:5:5: marker +flags-gen:env requires a value",
+		},
+		{
+			name:     "malformed bool",
+			source:   "type Config struct {\n\t// +flags-gen:hidden=yes\n\tHost string\n}",
+			expected: "test.go:5:23: marker +flags-gen:hidden expects true or false",
+		},
+		{
+			name:     "invalid short flag",
+			source:   "type Config struct {\n\t// +flags-gen:short=ho\n\tHost string\n}",
+			expected: "test.go:5:22: marker +flags-gen:short: short flag \"ho\" must be a single ASCII letter or digit",
+		},
+		{
+			name:     "malformed quoted value",
+			source:   "type Config struct {\n\t// +flags-gen:deprecated=\"unterminated\n\tHost string\n}",
+			expected: "test.go:5:27: marker +flags-gen:deprecated: malformed quoted value",
+		},
+		{
+			name:     "duplicate marker",
+			source:   "type Config struct {\n\t// +flags-gen:env=A\n\t// +flags-gen:env=B\n\tHost string\n}",
+			expected: "test.go:6:5: duplicate marker +flags-gen:env (first set at",
+		},
+		{
+			name:     "shorthand-deprecated without short",
+			source:   "type Config struct {\n\t// +flags-gen:shorthand-deprecated=\"use --host\"\n\tHost string\n}",
+			expected: "test.go:5:5: marker +flags-gen:shorthand-deprecated requires a +flags-gen:short shorthand",
+		},
+		{
+			name:     "unknown completion",
+			source:   "type Config struct {\n\t// +flags-gen:complete=hosts\n\tHost string\n}",
+			expected: "test.go:5:25: marker +flags-gen:complete: unknown completion \"hosts\"",
+		},
+		{
+			name:     "invalid sensitive tag",
+			source:   "type Config struct {\n\tAPIKey string `sensitive:\"yes\"`\n}",
+			expected: "test.go:5:16: invalid sensitive tag \"yes\": must be true or false",
+		},
+		{
+			name:     "invalid method name",
+			source:   "// +flags-gen:method=addFlags\ntype Config struct {\n\tHost string\n}",
+			expected: "test.go:4:22: marker +flags-gen:method: method name \"addFlags\" must be an exported Go identifier",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := parseTestSource(t, "package main\n\n// +flags-gen\n"+test.source+"\n")
+		if err == nil {
+			t.Errorf("%s: expected an error", test.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: error %q does not contain %q", test.name, err, test.expected)
+		}
+	}
+}
+
+func TestParser_FlagConstraints(t *testing.T) {
+	structs, err := parseTestSource(t, `package main
+
+// +flags-gen
+// +flags-gen:mutually-exclusive=tls-cert,insecure
+// +flags-gen:required-together=tls-cert,tls-key
+// +flags-gen:one-required=tls-cert,insecure
+type TLSConfig struct {
+	TLSCert  string `+"`json:\"tlsCert\"`"+`
+	TLSKey   string `+"`json:\"tlsKey\"`"+`
+	Insecure bool   `+"`json:\"insecure\"`"+`
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	config := structs[0]
+	if len(config.MutuallyExclusive) != 1 || strings.Join(config.MutuallyExclusive[0], ",") != "tls-cert,insecure" {
+		t.Errorf("Unexpected mutually exclusive flags: %v", config.MutuallyExclusive)
+	}
+	if len(config.RequiredTogether) != 1 || strings.Join(config.RequiredTogether[0], ",") != "tls-cert,tls-key" {
+		t.Errorf("Unexpected required together flags: %v", config.RequiredTogether)
+	}
+	if len(config.OneRequired) != 1 || strings.Join(config.OneRequired[0], ",") != "tls-cert,insecure" {
+		t.Errorf("Unexpected one required flags: %v", config.OneRequired)
+	}
+
+	_, err = parseTestSource(t, `package main
+
+// +flags-gen
+// +flags-gen:mutually-exclusive=tls-cert,insecure
+type TLSConfig struct {
+	TLSCert string `+"`json:\"tlsCert\"`"+`
+}
+`)
+	expected := `test.go:4:4: marker +flags-gen:mutually-exclusive refers to unknown flag "insecure" (flags of TLSConfig: tls-cert)`
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error containing %q, got %v", expected, err)
+	}
+}
+
+func TestParser_CompletionMarkers(t *testing.T) {
+	structs, err := parseTestSource(t, `package main
+
+// +flags-gen
+type Config struct {
+	// +flags-gen:complete=files:*.yaml,*.yml
+	ConfigFile string
+	// +flags-gen:complete=dirs
+	DataDir string
+	// +flags-gen:complete=func:CompleteNamespaces
+	Namespace string
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	fields := structs[0].Fields
+	if c := fields[0].Completion; c == nil || c.Kind != types.CompleteFiles || strings.Join(c.Extensions, ",") != "yaml,yml" {
+		t.Errorf("Unexpected file completion: %+v", c)
+	}
+	if c := fields[1].Completion; c == nil || c.Kind != types.CompleteDirs {
+		t.Errorf("Unexpected dir completion: %+v", c)
+	}
+	if c := fields[2].Completion; c == nil || c.Kind != types.CompleteFunc || c.Func != "CompleteNamespaces" {
+		t.Errorf("Unexpected func completion: %+v", c)
+	}
+}
+
+func TestParser_Sensitive(t *testing.T) {
+	structs, err := parseTestSource(t, `package main
+
+// +flags-gen
+type Config struct {
+	APIKey string `+"`sensitive:\"true\"`"+`
+	// +flags-gen:sensitive
+	// +flags-gen:from-file
+	Password string
+	Host string `+"`sensitive:\"false\"`"+`
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	expected := map[string]bool{"APIKey": true, "Password": true, "Host": false}
+	for _, field := range structs[0].Fields {
+		if field.Sensitive != expected[field.Name] {
+			t.Errorf("Field %s: expected Sensitive=%v, got %v", field.Name, expected[field.Name], field.Sensitive)
+		}
+		if field.FromFile != (field.Name == "Password") {
+			t.Errorf("Field %s: unexpected FromFile=%v", field.Name, field.FromFile)
+		}
+	}
+}
+
+func TestParser_UnsupportedTypes(t *testing.T) {
+	structs, err := parseTestSource(t, `package main
+
+// +flags-gen
+type Config struct {
+	Labels map[string]string `+"`json:\"labels\" yaml:\"labels\"`"+`
+	Parent *Config
+	Pair   [2]int
+}
+`, WithStrict(false))
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	expected := []string{"map[string]string", "*Config", "[2]int"}
+	for i, field := range structs[0].Fields {
+		if field.Type != expected[i] || field.FlagMethod != "" {
+			t.Errorf("Field %s: expected unsupported type %s, got type %s with flag method %q", field.Name, expected[i], field.Type, field.FlagMethod)
+		}
+	}
+	if tag := structs[0].Fields[0].Tag; tag != `json:"labels" yaml:"labels"` {
+		t.Errorf("Unexpected raw tag %s", tag)
+	}
+}
+
+func TestParser_Strict(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "pointer",
+			source:   "type Config struct {\n\tPort *int\n}",
+			expected: `test.go:5:2: field Port has unsupported type *int: use int instead; add +flags-gen:skip or a flag:"-" tag to leave it without a flag`,
+		},
+		{
+			name:     "array",
+			source:   "type Config struct {\n\tHost string\n\tTags [3]string\n}",
+			expected: "test.go:6:2: field Tags has unsupported type [3]string: use []string instead",
+		},
+		{
+			name:     "typo",
+			source:   "type Config struct {\n\tHost strng\n}",
+			expected: "test.go:5:2: field Host has unsupported type strng: did you mean string?",
+		},
+		{
+			name:     "map",
+			source:   "type Config struct {\n\tLabels map[string]string\n}",
+			expected: "test.go:5:2: field Labels has unsupported type map[string]string: supported types are []int, []string, bool",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTestSource(t, "package main\n\n// +flags-gen\n"+tt.source)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+
+	structs, err := parseTestSource(t, `package main
+
+// +flags-gen
+type Config struct {
+	Host string
+
+	// +flags-gen:skip
+	Labels map[string]string
+	Parent *Config `+"`flag:\"-\"`"+`
+}
+`)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if len(structs[0].Fields) != 1 || structs[0].Fields[0].Name != "Host" {
+		t.Errorf("Expected only field Host, got %+v", structs[0].Fields)
+	}
+}
@@ -3,6 +3,11 @@
 // flag type mappings.
 package types
 
+import (
+	"fmt"
+	"time"
+)
+
 const (
 	// Type constants.
 	TypeString       = "string"
@@ -16,26 +21,247 @@ const (
 
 // FieldInfo represents information about a struct field that needs flag generation.
 type FieldInfo struct {
-	Name             string
-	Type             string
-	JSONTag          string
-	FlagName         string
-	Description      string
-	DefaultValue     interface{}
-	DefaultValueCode string
-	Required         bool
-	ShortFlag        string
-	FlagMethod       string
+	Name                string      `json:"name"`
+	Type                string      `json:"type"`
+	JSONTag             string      `json:"jsonTag,omitempty"`
+	FlagName            string      `json:"flagName"`
+	Description         string      `json:"description,omitempty"`
+	DefaultValue        interface{} `json:"default,omitempty"`
+	DefaultValueCode    string      `json:"defaultCode,omitempty"`
+	Required            bool        `json:"required,omitempty"`
+	ShortFlag           string      `json:"shortFlag,omitempty"`
+	FlagMethod          string      `json:"flagMethod,omitempty"`
+	Hidden              bool        `json:"hidden,omitempty"`
+	Deprecated          string      `json:"deprecated,omitempty"`
+	ShorthandDeprecated string      `json:"shorthandDeprecated,omitempty"`
+	Aliases             []string    `json:"aliases,omitempty"`
+	EnvVar              string      `json:"envVar,omitempty"`
+	Group               string      `json:"group,omitempty"`
+	Completion          *Completion `json:"completion,omitempty"`
+	Sensitive           bool        `json:"sensitive,omitempty"`
+	// Doc is the full doc comment, formatted as godoc prints it. Description is
+	// its first sentence, the whole text or the usage tag, and is what --help shows.
+	Doc string `json:"doc,omitempty"`
+	// Tag is the raw struct tag, without the enclosing backquotes.
+	Tag string `json:"tag,omitempty"`
+	// Pos is the position of the field name in the source file.
+	Pos Position `json:"position"`
+	// DefaultExpr is a Go expression for the default value, from a
+	// default:"=Expr" tag or a default-expr marker. It is copied into
+	// DefaultValueCode and leaves DefaultValue nil.
+	DefaultExpr string `json:"defaultExpr,omitempty"`
+	// Validation holds the constraints on the value from +kubebuilder:validation
+	// markers, or nil.
+	Validation *Validation `json:"validation,omitempty"`
+	// FromFile registers a companion FileFlagName flag (and FileEnvVar) naming a
+	// file to read the value from.
+	FromFile bool `json:"fromFile,omitempty"`
+}
+
+// FileFlagName returns the name of the flag naming a file to read the field's value from.
+func (f FieldInfo) FileFlagName() string {
+	return f.FlagName + "-file"
+}
+
+// FileEnvVar returns the environment variable naming a file to read the field's
+// value from, or "" when the field has no environment variable.
+func (f FieldInfo) FileEnvVar() string {
+	if f.EnvVar == "" {
+		return ""
+	}
+	return f.EnvVar + "_FILE"
+}
+
+// Position is a location in a Go source file.
+type Position struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// String returns the position as "file:line:col", or "-" when it is unknown.
+func (p Position) String() string {
+	if p.Line == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// Validation holds the constraints on a field value checked by the generated
+// ValidateFlags method.
+type Validation struct {
+	// Minimum and Maximum are Go literals bounding a numeric value, or "".
+	Minimum          string `json:"minimum,omitempty"`
+	Maximum          string `json:"maximum,omitempty"`
+	ExclusiveMinimum bool   `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum bool   `json:"exclusiveMaximum,omitempty"`
+	// MinLength and MaxLength bound the number of characters of a string value.
+	MinLength *int `json:"minLength,omitempty"`
+	MaxLength *int `json:"maxLength,omitempty"`
+	// Pattern is a regular expression a string value must match, or "".
+	Pattern string `json:"pattern,omitempty"`
+	// Enum lists the allowed values as Go literals.
+	Enum []string `json:"enum,omitempty"`
+	// MinItems and MaxItems bound the number of elements of a slice value.
+	MinItems *int `json:"minItems,omitempty"`
+	MaxItems *int `json:"maxItems,omitempty"`
+}
+
+// Completion kinds for shell completion of flag values.
+const (
+	CompleteFiles = "files"
+	CompleteDirs  = "dirs"
+	CompleteFunc  = "func"
+)
+
+// Completion describes how a flag value is completed by the shell.
+type Completion struct {
+	// Kind is CompleteFiles, CompleteDirs or CompleteFunc.
+	Kind string `json:"kind"`
+	// Extensions limits file completion to these extensions, without the leading dot.
+	Extensions []string `json:"extensions,omitempty"`
+	// Func is the cobra completion function for CompleteFunc.
+	Func string `json:"func,omitempty"`
 }
 
 // StructInfo represents information about a struct that needs flag generation.
 type StructInfo struct {
-	Name        string
-	PackageName string
-	Fields      []FieldInfo
-	Imports     []string
+	Name        string `json:"name"`
+	PackageName string `json:"packageName"`
+	Description string `json:"description,omitempty"`
+	// Doc is the full doc comment of the struct, formatted as godoc prints it.
+	Doc        string      `json:"doc,omitempty"`
+	Prefix     string      `json:"prefix,omitempty"`
+	MethodName string      `json:"methodName"`
+	Fields     []FieldInfo `json:"fields"`
+	// Imports are the import paths needed by the generated code, each
+	// optionally preceded by a package name and a space.
+	Imports []string `json:"imports,omitempty"`
+	// Pos is the position of the struct name in the source file.
+	Pos Position `json:"position"`
+
+	// WithPrefix generates <MethodName>WithPrefix variants taking a runtime flag name prefix.
+	WithPrefix bool `json:"withPrefix,omitempty"`
+	// Provenance generates Sources and PrintEffectiveConfig methods.
+	Provenance bool `json:"provenance,omitempty"`
+	// ConfigFile generates an ApplyConfigFile method.
+	ConfigFile bool `json:"configFile,omitempty"`
+	// Watch generates a Watch method reloading the config file on change. It implies ConfigFile.
+	Watch bool `json:"watch,omitempty"`
+
+	// Flag name groups passed to the cobra MarkFlags* constraint methods.
+	MutuallyExclusive [][]string `json:"mutuallyExclusive,omitempty"`
+	RequiredTogether  [][]string `json:"requiredTogether,omitempty"`
+	OneRequired       [][]string `json:"oneRequired,omitempty"`
+}
+
+// SchemaVersion is the version of the JSON encoding of Model. It changes when
+// a field is removed or changes meaning; fields may be added within a version.
+const SchemaVersion = "v1"
+
+// Model is the JSON document describing parsed structs, printed by flags-gen inspect.
+type Model struct {
+	SchemaVersion string       `json:"schemaVersion"`
+	Structs       []StructInfo `json:"structs"`
+}
+
+// NewModel returns the Model of structs. Duration defaults are encoded as
+// strings such as "1m30s", and the defaults and struct tags of sensitive
+// fields are left out.
+func NewModel(structs []StructInfo) Model {
+	model := Model{SchemaVersion: SchemaVersion, Structs: make([]StructInfo, len(structs))}
+	for i := range structs {
+		model.Structs[i] = structs[i]
+		fields := make([]FieldInfo, len(structs[i].Fields))
+		for j, field := range structs[i].Fields {
+			if d, ok := field.DefaultValue.(time.Duration); ok {
+				field.DefaultValue = d.String()
+			}
+			if field.Sensitive {
+				field.DefaultValue = nil
+				field.DefaultValueCode = ""
+				field.DefaultExpr = ""
+				field.Tag = ""
+			}
+			fields[j] = field
+		}
+		model.Structs[i].Fields = fields
+	}
+	return model
+}
+
+// HasCompletions returns true if any field of the struct has shell completion.
+func (s *StructInfo) HasCompletions() bool {
+	for i := range s.Fields {
+		if s.Fields[i].FlagMethod != "" && s.Fields[i].Completion != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasConstraints returns true if the struct declares any cobra flag constraints,
+// required flags included.
+func (s *StructInfo) HasConstraints() bool {
+	if len(s.MutuallyExclusive) > 0 || len(s.RequiredTogether) > 0 || len(s.OneRequired) > 0 {
+		return true
+	}
+	for i := range s.Fields {
+		if s.Fields[i].FlagMethod != "" && s.Fields[i].Required {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAliases returns true if any flag field of the struct accepts old names.
+func (s *StructInfo) HasAliases() bool {
+	for i := range s.Fields {
+		if s.Fields[i].FlagMethod != "" && len(s.Fields[i].Aliases) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSensitive returns true if any flag field of the struct holds a secret.
+func (s *StructInfo) HasSensitive() bool {
+	for i := range s.Fields {
+		if s.Fields[i].FlagMethod != "" && s.Fields[i].Sensitive {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFromFile returns true if any flag field of the struct can be read from a file.
+func (s *StructInfo) HasFromFile() bool {
+	for i := range s.Fields {
+		if s.Fields[i].FlagMethod != "" && s.Fields[i].FromFile {
+			return true
+		}
+	}
+	return false
+}
+
+// HasValidation returns true if any flag field of the struct has value constraints.
+func (s *StructInfo) HasValidation() bool {
+	for i := range s.Fields {
+		if s.Fields[i].FlagMethod != "" && s.Fields[i].Validation != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesRuntime returns true if the generated code for the struct needs the flagsrt runtime package.
+func (s *StructInfo) UsesRuntime() bool {
+	return s.Provenance || s.ConfigFile || s.Watch
 }
 
+// DefaultMethodName is the name of the generated flag registration method.
+const DefaultMethodName = "AddFlags"
+
 // SupportedTypes maps Go types to their pflags method names.
 var SupportedTypes = map[string]string{
 	"string":        "StringVar",
@@ -59,6 +285,33 @@ func GetFlagMethod(fieldType string) (string, bool) {
 	return method, exists
 }
 
+// durationUnits are the units DurationCode expresses durations in, largest first.
+var durationUnits = []struct {
+	name string
+	unit time.Duration
+}{
+	{"Hour", time.Hour},
+	{"Minute", time.Minute},
+	{"Second", time.Second},
+	{"Millisecond", time.Millisecond},
+	{"Microsecond", time.Microsecond},
+	{"Nanosecond", time.Nanosecond},
+}
+
+// DurationCode returns a Go expression for d in the largest unit that divides it
+// exactly, e.g. "90*time.Second" for 1m30s or "500*time.Millisecond" for 500ms.
+func DurationCode(d time.Duration) string {
+	if d == 0 {
+		return "0"
+	}
+	for _, u := range durationUnits {
+		if d%u.unit == 0 {
+			return fmt.Sprintf("%d*time.%s", d/u.unit, u.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", int64(d))
+}
+
 // HasShortFlag returns true if the field supports short flags (single character flags).
 func HasShortFlag(fieldType string) bool {
 	// Only simple types typically get short flags to avoid confusion